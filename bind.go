@@ -0,0 +1,325 @@
+package opm
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMemory is the memory used to parse multipart forms, the rest of
+// the files being stored on disk.
+const defaultMemory = 32 << 20
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+	fileHeaderType   = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType  = reflect.TypeOf([]*multipart.FileHeader(nil))
+	textUnmarshaler  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bindTimeLayouts  = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+	bindSourceTags   = []string{"param", "query", "header", "cookie"}
+	errBindNotStruct = errors.New("bind target must be a pointer to a struct")
+)
+
+// Bind decodes the request body into v according to its Content-Type,
+// then fills the fields of v tagged `param`, `query`, `header` and
+// `cookie`. Form fields are named by their `form` tag, or the field name
+// matched case-insensitively.
+func (c *context) Bind(v interface{}) error {
+	if err := c.bindBody(v); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, tag := range bindSourceTags {
+		if err := bindFields(rv.Elem(), tag, c.bindValues(tag), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *context) bindBody(v interface{}) error {
+	req := c.Request()
+	if req.ContentLength == 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	mediatype, _, err := mime.ParseMediaType(req.Header.Get(HeaderContentType))
+	if err != nil {
+		return ErrUnsupportedMediaType
+	}
+
+	switch {
+	case mediatype == MIMEApplicationJSON || strings.HasSuffix(mediatype, "+json"):
+		if err = json.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+			return bodyError(err)
+		}
+	case mediatype == MIMEApplicationXML || mediatype == MIMETextXML || strings.HasSuffix(mediatype, "+xml"):
+		if err = xml.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+			return bodyError(err)
+		}
+	case mediatype == MIMEApplicationForm || mediatype == MIMEMultipartForm:
+		if err = c.parseForm(); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+			return errBindNotStruct
+		}
+
+		var files map[string][]*multipart.FileHeader
+		if req.MultipartForm != nil {
+			files = req.MultipartForm.File
+		}
+
+		get := func(name string) []string {
+			if values, ok := req.PostForm[name]; ok {
+				return values
+			}
+
+			for k, values := range req.PostForm {
+				if strings.EqualFold(k, name) {
+					return values
+				}
+			}
+
+			return nil
+		}
+
+		return bindFields(rv.Elem(), "form", get, files)
+	default:
+		return ErrUnsupportedMediaType
+	}
+
+	return nil
+}
+
+// bodyError turns a decoding error into a 400 naming the failing field
+// when the decoder knows it.
+func bodyError(err error) error {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) && te.Field != "" {
+		return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for field %s, expected %s", te.Field, te.Type))
+	}
+
+	return NewHTTPError(http.StatusBadRequest, err.Error())
+}
+
+// bindValues returns the lookup of the values of a tag source.
+func (c *context) bindValues(tag string) func(string) []string {
+	req := c.Request()
+	switch tag {
+	case "param":
+		return func(name string) []string {
+			for i, n := range c.pnames {
+				if n == name && i < len(c.pvalues) {
+					return c.pvalues[i : i+1]
+				}
+			}
+
+			return nil
+		}
+	case "query":
+		return func(name string) []string { return c.QueryParams()[name] }
+	case "header":
+		return func(name string) []string { return req.Header[http.CanonicalHeaderKey(name)] }
+	}
+
+	return func(name string) []string {
+		var values []string
+		for _, cookie := range req.Cookies() {
+			if cookie.Name == name {
+				values = append(values, cookie.Value)
+			}
+		}
+
+		return values
+	}
+}
+
+// bindFields sets the fields of the struct rv tagged with tag from the
+// values found by get. Untagged struct fields are walked recursively.
+func bindFields(rv reflect.Value, tag string, get func(string) []string, files map[string][]*multipart.FileHeader) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		fv := rv.Field(i)
+		name := ft.Tag.Get(tag)
+		if name == "-" || (ft.PkgPath != "" && !ft.Anonymous) {
+			continue
+		}
+
+		if name == "" && fv.Kind() == reflect.Struct && !isScalar(fv.Type()) {
+			if err := bindFields(fv, tag, get, files); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !fv.CanSet() {
+			continue
+		}
+
+		if name == "" {
+			if tag != "form" {
+				continue
+			}
+
+			name = ft.Name
+		}
+
+		switch ft.Type {
+		case fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+
+			continue
+		case fileHeadersType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+
+			continue
+		}
+
+		values := get(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setField(fv, values); err != nil {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value %q for %s %s", strings.Join(values, ","), tag, name))
+		}
+	}
+
+	return nil
+}
+
+// isScalar reports whether values of t are set from a single string.
+func isScalar(t reflect.Type) bool {
+	return t == timeType || reflect.PtrTo(t).Implements(textUnmarshaler)
+}
+
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Ptr {
+		v := reflect.New(fv.Type().Elem())
+		if err := setField(v.Elem(), values); err != nil {
+			return err
+		}
+
+		fv.Set(v)
+		return nil
+	}
+
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 && !isScalar(fv.Type()) {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+
+		fv.Set(slice)
+		return nil
+	}
+
+	return setValue(fv, values[0])
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case timeType:
+		for _, layout := range bindTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+
+		return fmt.Errorf("invalid time %q", s)
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(n)
+	case reflect.Slice:
+		v.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// parseForm parses the urlencoded or multipart request form.
+func (c *context) parseForm() error {
+	req := c.Request()
+	if strings.HasPrefix(req.Header.Get(HeaderContentType), MIMEMultipartForm) {
+		if err := req.ParseMultipartForm(defaultMemory); err != nil && err != http.ErrNotMultipart {
+			return err
+		}
+
+		return nil
+	}
+
+	return req.ParseForm()
+}
@@ -0,0 +1,124 @@
+package opm
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bindUser struct {
+	ID      int       `param:"id" json:"-" xml:"-"`
+	Name    string    `json:"name" xml:"name" form:"name"`
+	Age     uint8     `json:"age" xml:"age" form:"age"`
+	Admin   bool      `query:"admin" json:"-" xml:"-"`
+	Tags    []string  `query:"tag" json:"-" xml:"-"`
+	Since   time.Time `query:"since" json:"-" xml:"-"`
+	Token   string    `header:"X-Token" json:"-" xml:"-"`
+	Session *string   `cookie:"sid" json:"-" xml:"-"`
+}
+
+func bindContext(method, target, contentType, body string) *context {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(HeaderContentType, contentType)
+	}
+
+	c := Make().NewContext(httptest.NewRecorder(), req).(*context)
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	return c
+}
+
+func TestContextBind(t *testing.T) {
+	cases := []struct {
+		contentType, body string
+	}{
+		{MIMEApplicationJSONCharsetUTF8, `{"name":"Jon","age":30}`},
+		{MIMEApplicationXML, `<user><name>Jon</name><age>30</age></user>`},
+		{MIMEApplicationForm, `name=Jon&age=30`},
+	}
+
+	for _, tc := range cases {
+		c := bindContext(http.MethodPost, "/users/7?admin=true&tag=a&tag=b&since=2020-01-02", tc.contentType, tc.body)
+		c.Request().Header.Set("X-Token", "secret")
+		c.Request().AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+
+		u := new(bindUser)
+		if assert.NoError(t, c.Bind(u), tc.contentType) {
+			assert.Equal(t, 7, u.ID)
+			assert.Equal(t, "Jon", u.Name)
+			assert.Equal(t, uint8(30), u.Age)
+			assert.True(t, u.Admin)
+			assert.Equal(t, []string{"a", "b"}, u.Tags)
+			assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), u.Since)
+			assert.Equal(t, "secret", u.Token)
+			if assert.NotNil(t, u.Session) {
+				assert.Equal(t, "abc", *u.Session)
+			}
+		}
+	}
+}
+
+func TestContextBindMultipart(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "Jon")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	c := bindContext(http.MethodPost, "/", mw.FormDataContentType(), body.String())
+
+	v := struct {
+		Name   string
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}{}
+
+	if assert.NoError(t, c.Bind(&v)) {
+		assert.Equal(t, "Jon", v.Name)
+		if assert.NotNil(t, v.Avatar) {
+			assert.Equal(t, "avatar.png", v.Avatar.Filename)
+		}
+	}
+}
+
+func TestContextBindErrors(t *testing.T) {
+	c := bindContext(http.MethodPost, "/", "text/csv", "a,b")
+	assert.Equal(t, ErrUnsupportedMediaType, c.Bind(new(bindUser)))
+
+	c = bindContext(http.MethodPost, "/", MIMEApplicationJSON, `{"age":"old"}`)
+	err := c.Bind(new(bindUser))
+	if assert.IsType(t, &HTTPError{}, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Contains(t, err.(*HTTPError).Message, "age")
+	}
+
+	c = bindContext(http.MethodGet, "/?admin=maybe", "", "")
+	err = c.Bind(new(bindUser))
+	if assert.IsType(t, &HTTPError{}, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Equal(t, `invalid value "maybe" for query admin`, err.(*HTTPError).Message)
+	}
+}
+
+func TestContextFormParams(t *testing.T) {
+	c := bindContext(http.MethodPost, "/?page=2", MIMEApplicationForm, "name=Jon&tag=a&tag=b")
+
+	form, err := c.FormParams("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Jon", form.Get("name"))
+		assert.Equal(t, "2", form.Get("page"))
+	}
+
+	form, err = c.FormParams("tag")
+	if assert.NoError(t, err) {
+		assert.Len(t, form, 1)
+		assert.Equal(t, []string{"a", "b"}, form["tag"])
+	}
+}
@@ -3,6 +3,8 @@ package opm
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net"
@@ -10,8 +12,19 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+)
+
+var (
+	uuidRegexp = regexp.MustCompile("^" + paramTypes["uuid"] + "$")
+
+	// jsonpCallbackRegexp matches the JavaScript identifiers, possibly
+	// dotted, accepted as JSONP callbacks.
+	jsonpCallbackRegexp = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$.]*$`)
 )
 
 type (
@@ -22,10 +35,11 @@ type (
 		// SetRequest sets `*http.Request`
 		SetRequest(*http.Request)
 
-		// Response returns `http.ResponseWriter`
-		Response() http.ResponseWriter
+		// Response returns `*Response`
+		Response() *Response
 
-		// SetResponse sets `http.ResponseWriter`
+		// SetResponse sets the response, wrapping a writer other than
+		// `*Response` in one.
 		SetResponse(http.ResponseWriter)
 
 		// Logger returns the Looger instance
@@ -46,6 +60,27 @@ type (
 		// Param returns path parameter by name.
 		Param(name string) string
 
+		// ParamInt returns path parameter by name as an int.
+		ParamInt(name string) (int, error)
+
+		// ParamInt64 returns path parameter by name as an int64.
+		ParamInt64(name string) (int64, error)
+
+		// ParamUint64 returns path parameter by name as an uint64.
+		ParamUint64(name string) (uint64, error)
+
+		// ParamFloat64 returns path parameter by name as a float64.
+		ParamFloat64(name string) (float64, error)
+
+		// ParamBool returns path parameter by name as a bool.
+		ParamBool(name string) (bool, error)
+
+		// ParamUUID returns path parameter by name as a lower-cased UUID.
+		ParamUUID(name string) (string, error)
+
+		// ParamDate returns path parameter by name as a YYYY-MM-DD date.
+		ParamDate(name string) (time.Time, error)
+
 		// ParamNames sets path parameter names.
 		SetParamNames(names ...string)
 
@@ -73,12 +108,24 @@ type (
 		// FormFile returns the multipart form file for the provided name.
 		FormFile(name string) (*multipart.FileHeader, error)
 
+		// FormParams returns the parsed form values, only those of the
+		// provided name unless it is empty.
+		FormParams(name string) (url.Values, error)
+
 		// HTML sends a blod response with  content type and status code
 		Blob(code int, contentType string, b []byte) (err error)
 
 		// Decode reads the next JSON-encoded value from request
 		Decode(interface{}) error
 
+		// Bind decodes the request body by its content type and fills the
+		// fields tagged param, query, header and cookie.
+		Bind(interface{}) error
+
+		// Validate validates the value with the validator registered on
+		// the Core.
+		Validate(interface{}) error
+
 		// Stream sends a streaming response with status code and content type.
 		Stream(code int, contentType string, r io.Reader) error
 
@@ -95,6 +142,21 @@ type (
 		// JSON sends a JSON response with status code
 		JSON(code int, data interface{}) error
 
+		// JSONPretty sends an indented JSON response with status code
+		JSONPretty(code int, data interface{}, indent string) error
+
+		// JSONP sends a JSONP response calling the callback with status code.
+		// A callback that is not a, possibly dotted, JavaScript identifier
+		// gives ErrBadRequest.
+		JSONP(code int, callback string, data interface{}) error
+
+		// XML sends an XML response with status code
+		XML(code int, data interface{}) error
+
+		// Negotiate sends data in the offered content type the request
+		// accepts best, JSON, XML, HTML or plain text.
+		Negotiate(code int, data interface{}, offers ...string) error
+
 		// JSON sends a JSON response with status code
 		String(code int, data string) error
 
@@ -151,16 +213,18 @@ type (
 	}
 
 	context struct {
-		lock     sync.RWMutex
-		request  *http.Request
-		response http.ResponseWriter
-		logger   Logger
-		query    url.Values
-		renderer Renderer
-		pnames   []string
-		pvalues  []string
-		body     map[string]interface{}
-		route    *Route
+		lock      sync.RWMutex
+		request   *http.Request
+		response  *Response
+		logger    Logger
+		query     url.Values
+		renderer  Renderer
+		validator Validator
+		pnames    []string
+		pvalues   []string
+		body      map[string]interface{}
+		route     *Route
+		scope     *Group
 	}
 )
 
@@ -172,12 +236,17 @@ func (c *context) SetRequest(r *http.Request) {
 	c.request = r
 }
 
-func (c *context) Response() http.ResponseWriter {
+func (c *context) Response() *Response {
 	return c.response
 }
 
 func (c *context) SetResponse(w http.ResponseWriter) {
-	c.response = w
+	if r, ok := w.(*Response); ok {
+		c.response = r
+		return
+	}
+
+	c.response = NewResponse(w)
 }
 
 func (c *context) SetLogger(l Logger) {
@@ -212,6 +281,81 @@ func (c *context) Param(key string) string {
 	return ""
 }
 
+func (c *context) ParamInt(name string) (int, error) {
+	v := c.Param(name)
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, paramError(name, v)
+	}
+
+	return i, nil
+}
+
+func (c *context) ParamInt64(name string) (int64, error) {
+	v := c.Param(name)
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, paramError(name, v)
+	}
+
+	return i, nil
+}
+
+func (c *context) ParamUint64(name string) (uint64, error) {
+	v := c.Param(name)
+	i, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, paramError(name, v)
+	}
+
+	return i, nil
+}
+
+func (c *context) ParamFloat64(name string) (float64, error) {
+	v := c.Param(name)
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, paramError(name, v)
+	}
+
+	return f, nil
+}
+
+func (c *context) ParamBool(name string) (bool, error) {
+	v := c.Param(name)
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, paramError(name, v)
+	}
+
+	return b, nil
+}
+
+func (c *context) ParamUUID(name string) (string, error) {
+	v := c.Param(name)
+	if !uuidRegexp.MatchString(v) {
+		return "", paramError(name, v)
+	}
+
+	return strings.ToLower(v), nil
+}
+
+func (c *context) ParamDate(name string) (time.Time, error) {
+	v := c.Param(name)
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, paramError(name, v)
+	}
+
+	return t, nil
+}
+
+// paramError returns a 400 error for a path parameter that can't be
+// converted.
+func paramError(name, value string) error {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value %q for param %s", value, name))
+}
+
 func (c *context) ParamNames() []string {
 	return c.pnames
 }
@@ -263,7 +407,21 @@ func (c *context) FormValue(name string) string {
 }
 
 func (c *context) FormParams(name string) (url.Values, error) {
-	return c.Request().Form, nil
+	if err := c.parseForm(); err != nil {
+		return nil, err
+	}
+
+	form := c.Request().Form
+	if name == "" {
+		return form, nil
+	}
+
+	params := url.Values{}
+	if values, ok := form[name]; ok {
+		params[name] = values
+	}
+
+	return params, nil
 }
 
 func (c *context) writeContentType(value string) {
@@ -324,13 +482,49 @@ func (c *context) JSON(code int, data interface{}) error {
 	return c.json(code, b)
 }
 
+func (c *context) JSONPretty(code int, data interface{}, indent string) error {
+	b, err := json.MarshalIndent(data, "", indent)
+	if err != nil {
+		return err
+	}
+
+	return c.json(code, b)
+}
+
+func (c *context) JSONP(code int, callback string, data interface{}) error {
+	if !jsonpCallbackRegexp.MatchString(callback) {
+		return ErrBadRequest
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBufferString(callback)
+	buf.WriteByte('(')
+	buf.Write(b)
+	buf.WriteString(");")
+	return c.Blob(code, MIMEApplicationJavaScriptCharsetUTF8, buf.Bytes())
+}
+
+func (c *context) XML(code int, data interface{}) error {
+	b, err := xml.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(code, MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), b...))
+}
+
 func (c *context) Reset(w http.ResponseWriter, r *http.Request) {
 	c.request = r
-	c.response = w
+	c.response.reset(w)
 	c.query = nil
 	c.pnames = nil
-	c.pvalues = nil
+	c.pvalues = c.pvalues[:0]
 	c.route = nil
+	c.scope = nil
 	c.body = make(map[string]interface{})
 }
 
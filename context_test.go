@@ -2,6 +2,7 @@ package opm
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type response struct {
@@ -267,3 +270,69 @@ func BenchmarkString(b *testing.B) {
 		c.String(http.StatusOK, "opm")
 	}
 }
+
+func TestContextTypedParams(t *testing.T) {
+	assert.NoError(t, RegisterParamType("hex", "[0-9a-f]+"))
+	assert.Error(t, RegisterParamType("bad", "[0-9"))
+
+	r := Make()
+	r.GET("/items/{id:int}/{slug:slug}", func(c Context) error {
+		id, err := c.ParamInt("id")
+		if err != nil {
+			return err
+		}
+
+		return c.String(http.StatusOK, fmt.Sprintf("%d %s", id, c.Param("slug")))
+	})
+	r.GET("/users/{uid:uuid}", func(c Context) error { return c.String(http.StatusOK, "uuid") })
+	r.GET("/days/{d:date}", func(c Context) error { return c.String(http.StatusOK, "date") })
+	r.GET("/colors/{c:hex}", func(c Context) error { return c.String(http.StatusOK, "hex") })
+	r.GET("/files/{rest:path}", func(c Context) error { return c.String(http.StatusOK, c.Param("rest")) })
+
+	testCases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/items/42/hello-world", http.StatusOK, "42 hello-world"},
+		{"/items/x/hello-world", http.StatusNotFound, ""},
+		{"/items/42/Hello", http.StatusNotFound, ""},
+		{"/users/123e4567-e89b-12d3-a456-426614174000", http.StatusOK, "uuid"},
+		{"/users/123", http.StatusNotFound, ""},
+		{"/days/2024-02-29", http.StatusOK, "date"},
+		{"/colors/ff00aa", http.StatusOK, "hex"},
+		{"/files/a/b/c.txt", http.StatusOK, "a/b/c.txt"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, tc.body, rec.Body.String(), tc.path)
+		}
+	}
+
+	c := r.NewContext(nil, nil)
+	c.SetParamNames("id", "uid", "d", "ok")
+	c.SetParamValues("12x", "123E4567-E89B-12D3-A456-426614174000", "2024-02-30", "true")
+
+	_, err := c.ParamInt("id")
+	he, ok := err.(*HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	}
+
+	uid, err := c.ParamUUID("uid")
+	assert.NoError(t, err)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", uid)
+
+	_, err = c.ParamDate("d")
+	assert.Error(t, err)
+
+	b, err := c.ParamBool("ok")
+	assert.NoError(t, err)
+	assert.True(t, b)
+}
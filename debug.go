@@ -0,0 +1,303 @@
+package opm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// debugSourceLines is the number of source lines shown around a frame.
+const debugSourceLines = 5
+
+type (
+	// panicError is a panic recovered in debug mode, with its stack.
+	panicError struct {
+		value interface{}
+		stack []uintptr
+	}
+
+	debugData struct {
+		Status  int
+		Title   string
+		Method  string
+		URL     string
+		Chain   []debugError
+		Frames  []debugFrame
+		Route   *RouteInfo
+		Params  [][2]string
+		Query   map[string][]string
+		Form    map[string][]string
+		Headers map[string][]string
+		Cookies []*http.Cookie
+		Body    map[string]interface{}
+	}
+
+	debugError struct {
+		Type    string
+		Message string
+	}
+
+	debugFrame struct {
+		Func   string
+		File   string
+		Line   int
+		Source []debugLine
+	}
+
+	debugLine struct {
+		Number  int
+		Text    string
+		Current bool
+	}
+)
+
+var debugTmpl = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8" />
+<title>{{ .Status }} {{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { color: #b00; }
+pre { background: #f6f6f6; padding: .5em; overflow: auto; }
+.current { background: #fdd; font-weight: bold; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ddd; padding: .2em .5em; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{ .Status }} {{ .Title }}</h1>
+<p>{{ .Method }} {{ .URL }}</p>
+<h2>Errors</h2>
+<ol>
+{{- range .Chain }}
+<li><code>{{ .Type }}</code> {{ .Message }}</li>
+{{- end }}
+</ol>
+{{- with .Route }}
+<h2>Route</h2>
+<table>
+<tr><th>Name</th><td>{{ .Name }}</td></tr>
+<tr><th>Path</th><td>{{ .Method }} {{ .Path }}</td></tr>
+{{- range $.Params }}
+<tr><th>{{ index . 0 }}</th><td>{{ index . 1 }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Frames }}
+<h2>Stack</h2>
+{{- range .Frames }}
+<p><code>{{ .Func }}</code><br />{{ .File }}:{{ .Line }}</p>
+{{- if .Source }}
+<pre>{{ range .Source }}<span{{ if .Current }} class="current"{{ end }}>{{ printf "%4d" .Number }}  {{ .Text }}</span>
+{{ end }}</pre>
+{{- end }}
+{{- end }}
+{{- end }}
+<h2>Query</h2>
+<table>{{ range $k, $v := .Query }}<tr><th>{{ $k }}</th><td>{{ range $v }}{{ . }}<br />{{ end }}</td></tr>{{ end }}</table>
+<h2>Form</h2>
+<table>{{ range $k, $v := .Form }}<tr><th>{{ $k }}</th><td>{{ range $v }}{{ . }}<br />{{ end }}</td></tr>{{ end }}</table>
+<h2>Headers</h2>
+<table>{{ range $k, $v := .Headers }}<tr><th>{{ $k }}</th><td>{{ range $v }}{{ . }}<br />{{ end }}</td></tr>{{ end }}</table>
+<h2>Cookies</h2>
+<table>{{ range .Cookies }}<tr><th>{{ .Name }}</th><td>{{ .Value }}</td></tr>{{ end }}</table>
+<h2>Body</h2>
+<table>{{ range $k, $v := .Body }}<tr><th>{{ $k }}</th><td>{{ printf "%#v" $v }}</td></tr>{{ end }}</table>
+</body>
+</html>
+`))
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
+// debugRecover turns the panics of h into errors carrying their stack.
+func debugRecover(h Handler) Handler {
+	return func(c Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				stack := make([]uintptr, 64)
+				stack = stack[:runtime.Callers(3, stack)]
+				err = &panicError{value: r, stack: stack}
+			}
+		}()
+
+		return h(c)
+	}
+}
+
+// debugAllowed reports whether the request comes from the local machine,
+// directly and through any proxy.
+func debugAllowed(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	if !isLoopback(host) {
+		return false
+	}
+
+	for _, name := range []string{HeaderXForwardedFor, HeaderXRealIP} {
+		for _, v := range req.Header.Values(name) {
+			for _, ip := range strings.Split(v, ",") {
+				if !isLoopback(strings.TrimSpace(ip)) {
+					return false
+				}
+			}
+		}
+	}
+
+	return req.Header.Get("Forwarded") == ""
+}
+
+func isLoopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// debugErrorHandler renders the developer error page of err.
+func (core *Core) debugErrorHandler(err error, c Context) {
+	core.logError(err)
+	if c.Response().Committed {
+		return
+	}
+
+	code := http.StatusInternalServerError
+	var he *HTTPError
+	if errors.As(err, &he) {
+		code = he.Code
+	}
+
+	req := c.Request()
+	data := debugData{
+		Status:  code,
+		Title:   http.StatusText(code),
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Chain:   errorChain(err),
+		Query:   c.QueryParams(),
+		Headers: req.Header,
+		Cookies: req.Cookies(),
+		Body:    c.Body(),
+	}
+
+	if form, ferr := c.FormParams(""); ferr == nil {
+		data.Form = form
+	}
+
+	if route := c.Route(); route != nil {
+		info := route.Info()
+		data.Route = &info
+		values := c.ParamValues()
+		for i, name := range c.ParamNames() {
+			if i < len(values) {
+				data.Params = append(data.Params, [2]string{name, values[i]})
+			}
+		}
+	}
+
+	// Only panics carry a stack, returned errors have none.
+	var pe *panicError
+	if errors.As(err, &pe) {
+		data.Frames = stackFrames(pe.stack)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := debugTmpl.Execute(buf, data); err != nil {
+		core.logError(err)
+		return
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	if err := c.HTMLBlob(code, buf.Bytes()); err != nil {
+		core.logError(err)
+	}
+}
+
+// errorChain lists err and the errors it wraps.
+func errorChain(err error) []debugError {
+	var chain []debugError
+	for err != nil {
+		chain = append(chain, debugError{Type: fmt.Sprintf("%T", err), Message: err.Error()})
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				chain = append(chain, errorChain(inner)...)
+			}
+
+			return chain
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+
+	return chain
+}
+
+// stackFrames resolves the program counters, with the source of the first
+// frame outside the Go runtime.
+func stackFrames(pcs []uintptr) []debugFrame {
+	var frames []debugFrame
+	root := runtime.GOROOT()
+	withSource := false
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if f.Function != "" {
+			frame := debugFrame{Func: f.Function, File: f.File, Line: f.Line}
+			if !withSource && (root == "" || !strings.HasPrefix(f.File, root)) {
+				frame.Source = sourceLines(f.File, f.Line)
+				withSource = frame.Source != nil
+			}
+
+			frames = append(frames, frame)
+		}
+
+		if !more {
+			return frames
+		}
+	}
+}
+
+// sourceLines returns the lines of the file around line.
+func sourceLines(file string, line int) []debugLine {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []debugLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if n < line-debugSourceLines {
+			continue
+		}
+
+		if n > line+debugSourceLines {
+			break
+		}
+
+		lines = append(lines, debugLine{Number: n, Text: scanner.Text(), Current: n == line})
+	}
+
+	return lines
+}
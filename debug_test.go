@@ -0,0 +1,91 @@
+package opm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugAllowed(t *testing.T) {
+	cases := []struct {
+		remote, forwarded string
+		allowed           bool
+	}{
+		{"127.0.0.1:1234", "", true},
+		{"[::1]:1234", "", true},
+		{"192.0.2.1:1234", "", false},
+		{"127.0.0.1:1234", "192.0.2.1", false},
+		{"127.0.0.1:1234", "127.0.0.1, ::1", true},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			req.Header.Set(HeaderXForwardedFor, tc.forwarded)
+		}
+
+		assert.Equal(t, tc.allowed, debugAllowed(req), tc.remote+" "+tc.forwarded)
+	}
+}
+
+func TestOPMDebug(t *testing.T) {
+	r := Make()
+	r.Logger = &testLogger{}
+	r.GET("/users/{id}", func(c Context) error {
+		c.Set("user", "jon")
+		return fmt.Errorf("load user: %w", errors.New("connection refused"))
+	}).Name("users.show")
+	r.GET("/panic", func(c Context) error {
+		panic("boom")
+	})
+
+	serve := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/users/7?tab=posts", "127.0.0.1:1234")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	r.Debug = true
+	rec = serve("/users/7?tab=posts", "127.0.0.1:1234")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	for _, s := range []string{
+		"load user: connection refused",
+		"<code>*errors.errorString</code> connection refused",
+		"users.show",
+		"<th>id</th><td>7</td>",
+		"<th>tab</th><td>posts<br />",
+		"<th>sid</th><td>abc</td>",
+		"<th>user</th><td>&#34;jon&#34;</td>",
+	} {
+		assert.Contains(t, rec.Body.String(), s)
+	}
+	assert.NotContains(t, rec.Body.String(), "<h2>Stack</h2>")
+
+	rec = serve("/panic", "127.0.0.1:1234")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "panic: boom")
+	assert.Contains(t, rec.Body.String(), "<h2>Stack</h2>")
+	assert.Contains(t, rec.Body.String(), "debug_test.go")
+	assert.Contains(t, rec.Body.String(), `class="current"`)
+
+	rec = serve("/users/7", "192.0.2.1:1234")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = serve("/panic", "192.0.2.1:1234")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "boom")
+}
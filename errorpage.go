@@ -0,0 +1,81 @@
+package opm
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// ErrorPages renders the error responses sent to HTML clients through the
+// Renderer, with the template of their status in pages, or the
+// "errors/<status>.html" one by default, e.g. "errors/404.html". The
+// templates get the "error", "status", "title" and "request" values.
+// Clients preferring JSON still get JSON, and a plain text status line is
+// sent when there is no Renderer or template. The handlers set on the
+// Core or a group are kept.
+func (core *Core) ErrorPages(pages map[int]string) *Core {
+	core.errorPages = make(map[int]string, len(pages))
+	for code, name := range pages {
+		core.errorPages[code] = name
+	}
+
+	return core
+}
+
+// hasErrorPage reports whether the error responses to c are error pages.
+func (core *Core) hasErrorPage(c Context) bool {
+	if core.errorPages == nil {
+		return false
+	}
+
+	req := c.Request()
+	if req.Header.Get(HeaderAccept) == "" {
+		mediatype, _, _ := mime.ParseMediaType(req.Header.Get(HeaderContentType))
+		return mediatype != MIMEApplicationJSON
+	}
+
+	return acceptedType(req, MIMETextHTML, MIMEApplicationJSON) == MIMETextHTML
+}
+
+// errorPage renders the error page of the status, or sends the status
+// line as plain text.
+func (core *Core) errorPage(c Context, code int, err error) error {
+	name, explicit := core.errorPages[code]
+	if !explicit {
+		name = StrConcat("errors/", strconv.Itoa(code), ".html")
+	}
+
+	if r := c.Renderer(); r != nil {
+		data := Map{
+			"error":   err,
+			"status":  code,
+			"title":   http.StatusText(code),
+			"request": c.Request(),
+		}
+
+		buf := new(bytes.Buffer)
+		rerr := r.Render(buf, name, data)
+		if rerr == nil {
+			return c.HTMLBlob(code, buf.Bytes())
+		}
+
+		if explicit {
+			core.logError(rerr)
+		}
+	}
+
+	return c.String(code, StrConcat(strconv.Itoa(code), " ", http.StatusText(code)))
+}
+
+// pageHandler returns the handler sending the error page of the status
+// when the client gets error pages, h otherwise.
+func (core *Core) pageHandler(code int, err error, h Handler) Handler {
+	return func(c Context) error {
+		if core.hasErrorPage(c) {
+			return core.errorPage(c, code, err)
+		}
+
+		return h(c)
+	}
+}
@@ -0,0 +1,77 @@
+package opm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pageRenderer struct{}
+
+func (pageRenderer) Render(w io.Writer, name string, data interface{}) error {
+	m := data.(Map)
+	if name == "errors/500.html" {
+		return errors.New("template not found")
+	}
+
+	_, err := fmt.Fprintf(w, "%s %d %s %s", name, m["status"], m["error"], m["request"].(*http.Request).URL.Path)
+	return err
+}
+
+func TestOPMErrorPages(t *testing.T) {
+	r := Make()
+	r.Renderer = pageRenderer{}
+	r.ErrorPages(map[int]string{http.StatusNotFound: "404.html"})
+	r.GET("/forbidden", func(Context) error { return ErrForbidden })
+	r.GET("/unknown", func(Context) error { return errors.New("boom") })
+	r.GET("/invalid", func(Context) error {
+		return ValidationErrors{newFieldError("name", "required", "", "is required")}
+	})
+
+	cases := []struct {
+		method, path, accept string
+		code                 int
+		contentType, body    string
+	}{
+		{http.MethodGet, "/missing", "text/html", http.StatusNotFound, MIMETextHTMLCharsetUTF8,
+			"404.html 404 code=404, message=Not Found /missing"},
+		{http.MethodGet, "/forbidden", "", http.StatusForbidden, MIMETextHTMLCharsetUTF8,
+			"errors/403.html 403 code=403, message=Forbidden /forbidden"},
+		{http.MethodPost, "/forbidden", "text/html", http.StatusMethodNotAllowed, MIMETextHTMLCharsetUTF8,
+			"errors/405.html 405 code=405, message=Method Not Allowed /forbidden"},
+		{http.MethodGet, "/unknown", "text/html", http.StatusInternalServerError, MIMETextPlainCharsetUTF8,
+			"500 Internal Server Error"},
+		{http.MethodGet, "/forbidden", MIMEApplicationJSON, http.StatusForbidden, MIMEApplicationJSONCharsetUTF8,
+			`{"message":"Forbidden"}`},
+		{http.MethodGet, "/invalid", "text/html", http.StatusUnprocessableEntity, MIMEApplicationJSONCharsetUTF8,
+			`{"errors":[{"field":"name","rule":"required","message":"is required"}],"message":"Unprocessable Entity"}`},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.accept != "" {
+			req.Header.Set(HeaderAccept, tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		assert.Equal(t, tc.contentType, rec.Header().Get(HeaderContentType), tc.path)
+		assert.Equal(t, tc.body, rec.Body.String(), tc.path)
+	}
+
+	r.SystemErrorHandler = func(c Context) error { return c.NoContent(http.StatusServiceUnavailable) }
+	code, _ := request(http.MethodGet, "/invalid", r)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	r = Make().ErrorPages(nil)
+	r.GET("/forbidden", func(Context) error { return ErrForbidden })
+	code, body := request(http.MethodGet, "/forbidden", r)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "403 Forbidden", body)
+}
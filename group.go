@@ -1,11 +1,25 @@
 package opm
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 type Group struct {
 	prefix     string
+	name       string
+	host       string
+	hostReg    *routeRegexp
+	hostErr    error
+	meta       map[string]interface{}
+	tags       []string
 	middleware []MiddlewareFunc
 	core       *Core
+	parent     *Group
+
+	notFoundHandler         Handler
+	methodNotAllowedHandler Handler
+	errorHandler            HTTPErrorHandler
 }
 
 func (g *Group) Prefix(prefix string) *Group {
@@ -13,6 +27,116 @@ func (g *Group) Prefix(prefix string) *Group {
 	return g
 }
 
+// Host restricts the routes added to the group to a host template. An
+// invalid template is reported by the routes added afterwards.
+func (g *Group) Host(host string) *Group {
+	g.host = host
+	g.hostReg, g.hostErr = nil, nil
+	if host != "" {
+		g.hostReg, g.hostErr = newRouteRegexp(host, regexpTypeHost)
+	}
+
+	return g
+}
+
+// Name adds a prefix to the names of the routes added to the group, e.g.
+// Name("admin.") names "admin.users" a route named "users".
+func (g *Group) Name(prefix string) *Group {
+	g.name = StrConcat(g.name, prefix)
+	return g
+}
+
+// Use adds middleware to the routes added to the group afterwards.
+func (g *Group) Use(m ...MiddlewareFunc) *Group {
+	g.middleware = append(g.middleware, m...)
+	return g
+}
+
+// NotFound sets the handler of the requests under the group prefix that
+// match no route.
+func (g *Group) NotFound(h Handler) *Group {
+	g.notFoundHandler = h
+	g.core.addScope(g)
+	return g
+}
+
+// MethodNotAllowed sets the handler of the requests under the group
+// prefix whose method is not allowed.
+func (g *Group) MethodNotAllowed(h Handler) *Group {
+	g.methodNotAllowedHandler = h
+	g.core.addScope(g)
+	return g
+}
+
+// ErrorHandler sets the handler of the errors returned for the requests
+// of the group, e.g. to answer JSON errors under /api.
+func (g *Group) ErrorHandler(h HTTPErrorHandler) *Group {
+	g.errorHandler = h
+	g.core.addScope(g)
+	return g
+}
+
+// getNotFound returns the not found handler of the group or its parents.
+func (g *Group) getNotFound() Handler {
+	for ; g != nil; g = g.parent {
+		if g.notFoundHandler != nil {
+			return g.notFoundHandler
+		}
+	}
+
+	return nil
+}
+
+// getMethodNotAllowed returns the method not allowed handler of the group
+// or its parents.
+func (g *Group) getMethodNotAllowed() Handler {
+	for ; g != nil; g = g.parent {
+		if g.methodNotAllowedHandler != nil {
+			return g.methodNotAllowedHandler
+		}
+	}
+
+	return nil
+}
+
+// getErrorHandler returns the error handler of the group or its parents.
+func (g *Group) getErrorHandler() HTTPErrorHandler {
+	for ; g != nil; g = g.parent {
+		if g.errorHandler != nil {
+			return g.errorHandler
+		}
+	}
+
+	return nil
+}
+
+// contains reports whether the request is under the group prefix and host.
+func (g *Group) contains(req *http.Request) bool {
+	if g.hostErr != nil || (g.hostReg != nil && !g.hostReg.Math(req)) {
+		return false
+	}
+
+	prefix := strings.TrimSuffix(g.prefix, "/")
+	p := getPath(req)
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// Meta sets a default metadata value for the routes added to the group.
+func (g *Group) Meta(key string, value interface{}) *Group {
+	if g.meta == nil {
+		g.meta = make(map[string]interface{})
+	}
+
+	g.meta[key] = value
+	return g
+}
+
+// Tags adds default tags to the routes added to the group.
+func (g *Group) Tags(tags ...string) *Group {
+	g.tags = append(g.tags, tags...)
+	return g
+}
+
 func (g *Group) Path(path string) *Route {
 	return g.core.NewRoute().Path(path)
 }
@@ -73,7 +197,21 @@ func (g *Group) add(method, path string, h Handler, middleware ...MiddlewareFunc
 	m = append(m, middleware...)
 
 	rPath := joinPaths(g.prefix, path)
-	return g.core.Path(rPath).Handler(h).Method(method).Use(m...)
+	route := g.core.NewRoute()
+	route.group = g
+	route.namePrefix = g.name
+	route.Path(rPath).Handler(h).Method(method).Use(m...)
+	if g.hostErr != nil {
+		route.err = g.hostErr
+	} else if g.host != "" {
+		route.Host(g.host)
+	}
+
+	for k, v := range g.meta {
+		route.Meta(k, v)
+	}
+
+	return route.Tags(g.tags...)
 }
 
 func (g *Group) File(path, file string) {
@@ -84,13 +222,27 @@ func (g *Group) File(path, file string) {
 	g.Path(g.prefix + path).Handler(h).Method(http.MethodGet)
 }
 
+// Static serves files from the root directory under the group prefix.
+func (g *Group) Static(path, root string) *Route {
+	if root == "" {
+		root = "."
+	}
+
+	return g.core.static(path, root, g.GET)
+}
+
 func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	g.core.Group(g.prefix+prefix, m...)
 
-	return g.core.Group(g.prefix+prefix, m...)
+	child := g.core.Group(g.prefix+prefix, m...).Host(g.host).Tags(g.tags...).Name(g.name)
+	child.parent = g
+	for k, v := range g.meta {
+		child.Meta(k, v)
+	}
+
+	return child
 }
 
 func (core *Core) Group(prefix string, m ...MiddlewareFunc) *Group {
@@ -41,3 +41,105 @@ func TestGroupFile(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, expectedData, rec.Body.Bytes())
 }
+
+func TestGroupGroup(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return c.String(http.StatusOK, c.Request().URL.Path) }
+	api := r.Group("/api").Name("api.")
+	api.Group("/v1").Name("v1.").GET("/users", h).Name("users")
+
+	assert.Len(t, r.routeTable().routes, 1)
+	assert.NotNil(t, r.GetRoute("api.v1.users"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "/api/v1/users", rec.Body.String())
+}
+
+func TestGroupUse(t *testing.T) {
+	r := Make()
+	g := r.Group("/group")
+	g.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			c.Response().Header().Set("X-Group", "true")
+			return next(c)
+		}
+	})
+	g.GET("/", func(c Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/group", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "true", rec.Header().Get("X-Group"))
+}
+
+func TestGroupStatic(t *testing.T) {
+	r := Make()
+	r.Group("/group").Static("/assets", "_fixture/assets")
+
+	expectedData, err := ioutil.ReadFile("_fixture/assets/demo.jpg")
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/group/assets/demo.jpg", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, expectedData, rec.Body.Bytes())
+}
+
+func TestGroupHandlers(t *testing.T) {
+	r := Make()
+	r.NotFoundHandler = func(c Context) error { return c.HTML(http.StatusNotFound, "<p>not found</p>") }
+
+	api := r.Group("/api")
+	api.NotFound(func(c Context) error {
+		return NewHTTPError(http.StatusNotFound, "resource not found")
+	}).ErrorHandler(func(err error, c Context) {
+		code := http.StatusInternalServerError
+		if he, ok := err.(*HTTPError); ok {
+			code = he.Code
+		}
+
+		c.JSON(code, map[string]string{"error": err.Error()})
+	})
+	api.GET("/users", func(Context) error { return NewHTTPError(http.StatusForbidden, "forbidden") })
+	api.Group("/v1").GET("/posts", func(Context) error { return ErrNotFound })
+
+	admin := r.Group("/admin").ErrorHandler(func(err error, c Context) {
+		he := err.(*HTTPError)
+		c.JSON(he.Code, map[string]string{"error": err.Error()})
+	})
+	admin.GET("/users", func(c Context) error { return c.NoContent(http.StatusOK) })
+	r.Group("/tenant").Host("{tenant").ErrorHandler(func(err error, c Context) {
+		c.JSON(http.StatusTeapot, nil)
+	}).GET("/users", func(c Context) error { return c.NoContent(http.StatusOK) })
+
+	cases := []struct {
+		method, path string
+		code         int
+		contentType  string
+	}{
+		{http.MethodGet, "/api/missing", http.StatusNotFound, MIMEApplicationJSONCharsetUTF8},
+		{http.MethodGet, "/api/users", http.StatusForbidden, MIMEApplicationJSONCharsetUTF8},
+		{http.MethodGet, "/api/v1/posts", http.StatusNotFound, MIMEApplicationJSONCharsetUTF8},
+		{http.MethodGet, "/apis", http.StatusNotFound, MIMETextHTMLCharsetUTF8},
+		{http.MethodGet, "/missing", http.StatusNotFound, MIMETextHTMLCharsetUTF8},
+		{http.MethodGet, "/admin/missing", http.StatusNotFound, MIMEApplicationJSONCharsetUTF8},
+		{http.MethodPost, "/admin/users", http.StatusMethodNotAllowed, MIMEApplicationJSONCharsetUTF8},
+		{http.MethodGet, "/tenant/users", http.StatusNotFound, MIMETextHTMLCharsetUTF8},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		assert.Equal(t, tc.contentType, rec.Header().Get(HeaderContentType), tc.path)
+	}
+
+	err := r.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "/tenant/users")
+	}
+}
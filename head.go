@@ -0,0 +1,78 @@
+package opm
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headResponseWriter serves a HEAD request through a GET handler: the body
+// is dropped, its size is sent as Content-Length unless the handler set one.
+type headResponseWriter struct {
+	http.ResponseWriter
+	code        int
+	size        int
+	wroteHeader bool
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	w.size += len(b)
+	return len(b), nil
+}
+
+func (w *headResponseWriter) Flush() {
+	w.finish()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the delayed header once the handler is done.
+func (w *headResponseWriter) finish() {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	header := w.Header()
+	if header.Get(HeaderContentLength) == "" && bodyAllowed(w.code) {
+		header.Set(HeaderContentLength, strconv.Itoa(w.size))
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// implicitHead runs the GET handler h for a HEAD request. When h fails
+// before writing a header, the error handler writes the status.
+func implicitHead(h Handler) Handler {
+	return func(c Context) (err error) {
+		res := c.Response()
+		w := &headResponseWriter{ResponseWriter: res.Writer}
+		res.Writer = w
+		defer func() {
+			res.Writer = w.ResponseWriter
+			if err == nil || w.code != 0 {
+				w.finish()
+			}
+		}()
+
+		return h(c)
+	}
+}
+
+func bodyAllowed(code int) bool {
+	if code >= 100 && code <= 199 {
+		return false
+	}
+
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
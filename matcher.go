@@ -0,0 +1,128 @@
+package opm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Matcher is a condition a request must meet for a route to match.
+	Matcher interface {
+		Match(*http.Request) bool
+	}
+
+	// MatcherFunc adapts a function to the Matcher interface.
+	MatcherFunc func(*http.Request) bool
+
+	headerMatcher map[string]string
+
+	queryMatcher struct {
+		key string
+		reg *routeRegexp
+	}
+
+	schemeMatcher []string
+)
+
+func (f MatcherFunc) Match(req *http.Request) bool {
+	return f(req)
+}
+
+// Match requires every header, an empty value only requires presence.
+func (m headerMatcher) Match(req *http.Request) bool {
+	for k, v := range m {
+		values, ok := req.Header[k]
+		if !ok {
+			return false
+		}
+
+		if v != "" && !InArrayString(values, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newQueryMatcher(key, value string) (*queryMatcher, error) {
+	reg, err := newRouteRegexp(value, regexpTypeQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryMatcher{key: key, reg: reg}, nil
+}
+
+// Match requires the query key, and its value to match the template
+// unless the template is empty.
+func (m *queryMatcher) Match(req *http.Request) bool {
+	_, ok := m.value(req)
+	return ok
+}
+
+func (m *queryMatcher) value(req *http.Request) (string, bool) {
+	values, ok := req.URL.Query()[m.key]
+	if !ok {
+		return "", false
+	}
+
+	if m.reg.template == "" {
+		return "", true
+	}
+
+	for _, v := range values {
+		if m.reg.regexp.MatchString(v) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// setValues appends the variable values of the query template.
+func (m *queryMatcher) setValues(req *http.Request, values []string) []string {
+	if len(m.reg.VarsN) == 0 {
+		return values
+	}
+
+	v, _ := m.value(req)
+	return m.reg.setValues(v, values)
+}
+
+// pair builds the key/value query pair from route variables.
+func (m *queryMatcher) pair(vars map[string]string) ([]string, error) {
+	values := make([]interface{}, len(m.reg.VarsN))
+	for i, name := range m.reg.VarsN {
+		v, ok := vars[name]
+		if !ok {
+			return nil, fmt.Errorf("missing route variable %q", name)
+		}
+
+		if !m.reg.VarsR[i].MatchString(v) {
+			return nil, fmt.Errorf("variable %q doesn't match, expected %q", v, m.reg.VarsR[i].String())
+		}
+
+		values[i] = v
+	}
+
+	return []string{m.key, fmt.Sprintf(m.reg.reverse, values...)}, nil
+}
+
+func (m schemeMatcher) Match(req *http.Request) bool {
+	return InArrayString(m, requestScheme(req))
+}
+
+// requestScheme returns the scheme of the request URL, or of the
+// connection. Headers sent by the client are not trusted.
+func requestScheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+
+	if req.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
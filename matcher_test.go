@@ -0,0 +1,68 @@
+package opm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMatchers(t *testing.T) {
+	r := Make()
+	h := func(name string) Handler {
+		return func(c Context) error {
+			return c.String(http.StatusOK, name+c.Param("fmt"))
+		}
+	}
+
+	r.GET("/export", h("v2 ")).Headers("X-Api-Version", "2")
+	r.GET("/export", h("query ")).Queries("format", "{fmt:csv|json}")
+	r.GET("/export", h("https")).Schemes("https")
+	r.GET("/export", h("custom")).MatcherFunc(func(req *http.Request) bool {
+		return req.Header.Get("X-Custom") != ""
+	})
+
+	testCases := []struct {
+		target string
+		header map[string]string
+		code   int
+		body   string
+	}{
+		{"/export", map[string]string{"X-Api-Version": "2"}, http.StatusOK, "v2 "},
+		{"/export?format=csv", nil, http.StatusOK, "query csv"},
+		{"https://example.com/export", nil, http.StatusOK, "https"},
+		{"https://example.com/export", map[string]string{"X-Forwarded-Proto": "http"}, http.StatusOK, "https"},
+		{"/export", map[string]string{"X-Forwarded-Proto": "https"}, http.StatusNotFound, ""},
+		{"/export", map[string]string{"X-Custom": "1"}, http.StatusOK, "custom"},
+		{"/export?format=xml", nil, http.StatusNotFound, ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.target)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, tc.body, rec.Body.String(), tc.target)
+		}
+	}
+
+	var match RouteMatch
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	assert.False(t, r.Match(req, &match))
+	assert.Equal(t, ErrMatcherMismatch, match.MatchErr)
+
+	req = httptest.NewRequest(http.MethodPost, "/export", nil)
+	assert.False(t, r.Match(req, &match))
+	assert.Equal(t, ErrMethodNotAllowed, match.MatchErr)
+
+	u, err := r.routeTable().routes[1].URL("fmt", "json")
+	assert.NoError(t, err)
+	assert.Equal(t, "/export?format=json", u)
+}
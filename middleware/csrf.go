@@ -98,7 +98,7 @@ func CSRF(config CSRFConfig) opm.MiddlewareFunc {
 
 			c.Set("csrf", mask(realToken))
 
-			if !opm.InArrayString(safeMethods, c.Request().Method) {
+			if !isSafeMethod(c.Request()) {
 				if realToken == nil {
 					return c.String(http.StatusBadRequest, "invalid csrf token")
 				}
@@ -115,6 +115,12 @@ func CSRF(config CSRFConfig) opm.MiddlewareFunc {
 	}
 }
 
+// isSafeMethod reports whether both the method and the method before any
+// override are safe.
+func isSafeMethod(r *http.Request) bool {
+	return opm.InArrayString(safeMethods, r.Method) && opm.InArrayString(safeMethods, opm.OriginalMethod(r))
+}
+
 func requestToken(r *http.Request) []byte {
 	issued := r.Header.Get(headerName)
 
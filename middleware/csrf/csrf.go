@@ -178,7 +178,7 @@ func (cs *csrf) Handle(c opm.Context) error {
 
 	// HTTP methods not defined as idempotent ("safe") under RFC7231 require
 	// inspection.
-	if !opm.InArrayString(safeMethods, r.Method) {
+	if !opm.InArrayString(safeMethods, r.Method) || !opm.InArrayString(safeMethods, opm.OriginalMethod(r)) {
 		// Enforce an origin check for HTTPS connections. As per the Django CSRF
 		// implementation (https://goo.gl/vKA7GE) the Referer header is almost
 		// always present for same-domain HTTP requests.
@@ -0,0 +1,192 @@
+package opm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// negotiateOffers are the content types offered by Negotiate by default.
+var negotiateOffers = []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+
+var errNegotiateTemplate = errors.New("negotiate: text/html offer needs a template parameter or string data")
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept returns the media ranges of an Accept header.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediatype := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediatype == "" {
+			continue
+		}
+
+		if mediatype == "*" {
+			mediatype = "*/*"
+		}
+
+		i := strings.IndexByte(mediatype, '/')
+		if i < 0 {
+			continue
+		}
+
+		r := acceptRange{typ: mediatype[:i], subtype: mediatype[i+1:], q: 1}
+		for _, param := range fields[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return ranges
+}
+
+// quality returns the q-value of the most specific range matching the
+// media type, 0 when none matches.
+func quality(ranges []acceptRange, mediatype string) float64 {
+	i := strings.IndexByte(mediatype, '/')
+	if i < 0 {
+		return 0
+	}
+
+	typ, subtype := mediatype[:i], mediatype[i+1:]
+	q, specificity := 0.0, 0
+	for _, r := range ranges {
+		s := 0
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 3
+		case r.typ == typ && r.subtype == "*":
+			s = 2
+		case r.typ == "*" && r.subtype == "*":
+			s = 1
+		}
+
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+
+	return q
+}
+
+// acceptedType returns the offer the request accepts best, the first one
+// on a tie or without Accept header, and "" when none is acceptable.
+func acceptedType(req *http.Request, offers ...string) string {
+	header := req.Header.Get(HeaderAccept)
+	if header == "" {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+
+		return ""
+	}
+
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		mediatype, _, err := mime.ParseMediaType(offer)
+		if err != nil {
+			continue
+		}
+
+		if q := quality(ranges, mediatype); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+// addVary adds the header to the Vary response header unless present.
+func addVary(header http.Header, name string) {
+	for _, v := range header.Values(HeaderVary) {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return
+			}
+		}
+	}
+
+	header.Add(HeaderVary, name)
+}
+
+// Negotiate sends data in the content type the Accept header prefers
+// among the offers, JSON, XML and plain text by default. An HTML offer is
+// rendered through the Renderer with the template of its parameter, e.g.
+// "text/html; template=users/show.html", or sends string data as is.
+// It returns ErrNotAcceptable when no offer is acceptable.
+func (c *context) Negotiate(code int, data interface{}, offers ...string) error {
+	if len(offers) == 0 {
+		offers = negotiateOffers
+	}
+
+	addVary(c.Response().Header(), HeaderAccept)
+
+	offer := acceptedType(c.Request(), offers...)
+	if offer == "" {
+		return ErrNotAcceptable
+	}
+
+	mediatype, params, err := mime.ParseMediaType(offer)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mediatype == MIMEApplicationJSON:
+		return c.JSON(code, data)
+	case strings.HasSuffix(mediatype, "+json"):
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		return c.Blob(code, StrConcat(mediatype, "; ", charsetUTF8), b)
+	case mediatype == MIMEApplicationXML || mediatype == MIMETextXML:
+		return c.XML(code, data)
+	case mediatype == MIMETextHTML:
+		if name, ok := params["template"]; ok {
+			if c.Renderer() == nil {
+				return ErrRendererNotRegistered
+			}
+
+			buf := new(bytes.Buffer)
+			if err := c.Renderer().Render(buf, name, data); err != nil {
+				return err
+			}
+
+			return c.HTMLBlob(code, buf.Bytes())
+		}
+
+		if s, ok := data.(string); ok {
+			return c.HTML(code, s)
+		}
+
+		return errNegotiateTemplate
+	case mediatype == MIMETextPlain:
+		return c.String(code, fmt.Sprint(data))
+	}
+
+	if b, ok := data.([]byte); ok {
+		return c.Blob(code, mediatype, b)
+	}
+
+	return fmt.Errorf("negotiate: cannot encode data as %s", mediatype)
+}
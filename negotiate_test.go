@@ -0,0 +1,103 @@
+package opm
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type negotiateUser struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func (u negotiateUser) String() string {
+	return u.Name
+}
+
+type negotiateRenderer struct{}
+
+func (negotiateRenderer) Render(w io.Writer, name string, data interface{}) error {
+	_, err := io.WriteString(w, "<p>"+name+":"+data.(negotiateUser).Name+"</p>")
+	return err
+}
+
+func TestParseAccept(t *testing.T) {
+	ranges := parseAccept("text/html;level=1, application/*;q=0.5, */*;q=0.1, bad")
+	assert.Equal(t, []acceptRange{
+		{"text", "html", 1},
+		{"application", "*", 0.5},
+		{"*", "*", 0.1},
+	}, ranges)
+
+	assert.Equal(t, 1.0, quality(ranges, "text/html"))
+	assert.Equal(t, 0.5, quality(ranges, "application/json"))
+	assert.Equal(t, 0.1, quality(ranges, "image/png"))
+}
+
+func TestContextNegotiate(t *testing.T) {
+	user := negotiateUser{Name: "Jon"}
+	html := "text/html; template=user.html"
+
+	cases := []struct {
+		accept, contentType, body string
+		offers                    []string
+	}{
+		{"", MIMEApplicationJSONCharsetUTF8, `{"name":"Jon"}`, nil},
+		{"application/xml", MIMEApplicationXMLCharsetUTF8, xml.Header + `<user><name>Jon</name></user>`, nil},
+		{"text/plain;q=0.9, application/json;q=0.5", MIMETextPlainCharsetUTF8, "Jon", nil},
+		{"text/*, application/json;q=0.2", MIMETextHTMLCharsetUTF8, "<p>user.html:Jon</p>", []string{MIMEApplicationJSON, html}},
+		{"text/html;q=0, */*", MIMEApplicationJSONCharsetUTF8, `{"name":"Jon"}`, []string{html, MIMEApplicationJSON}},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.accept != "" {
+			req.Header.Set(HeaderAccept, tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		c := Make().NewContext(rec, req)
+		c.SetRenderer(negotiateRenderer{})
+
+		if assert.NoError(t, c.Negotiate(http.StatusOK, user, tc.offers...), tc.accept) {
+			assert.Equal(t, tc.contentType, rec.Header().Get(HeaderContentType), tc.accept)
+			assert.Equal(t, tc.body, rec.Body.String(), tc.accept)
+			assert.Equal(t, HeaderAccept, rec.Header().Get(HeaderVary))
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAccept, "image/png")
+	c := Make().NewContext(httptest.NewRecorder(), req)
+	assert.Equal(t, ErrNotAcceptable, c.Negotiate(http.StatusOK, user))
+}
+
+func TestContextJSONPAndPretty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := Make().NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, c.JSONP(http.StatusOK, "cb", Map{"a": 1}))
+	assert.Equal(t, MIMEApplicationJavaScriptCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, `cb({"a":1});`, rec.Body.String())
+
+	for _, callback := range []string{"", "1cb", "alert(1);cb", "cb</script>", "a b"} {
+		rec = httptest.NewRecorder()
+		c = Make().NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, ErrBadRequest, c.JSONP(http.StatusOK, callback, Map{"a": 1}), callback)
+		assert.Empty(t, rec.Body.String(), callback)
+	}
+
+	rec = httptest.NewRecorder()
+	c = Make().NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, c.JSONP(http.StatusOK, "$.app_1.cb", Map{"a": 1}))
+	assert.Equal(t, `$.app_1.cb({"a":1});`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	c = Make().NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, c.JSONPretty(http.StatusOK, Map{"a": 1}, "  "))
+	assert.Equal(t, "{\n  \"a\": 1\n}", rec.Body.String())
+}
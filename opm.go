@@ -3,12 +3,14 @@ package opm
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -45,6 +47,8 @@ var (
 	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	ErrForbidden                   = NewHTTPError(http.StatusForbidden)
 	ErrMethodNotAllowed            = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
+	ErrMatcherMismatch             = NewHTTPError(http.StatusNotFound, "route matchers do not match the request")
 	ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
 	ErrTooManyRequests             = NewHTTPError(http.StatusTooManyRequests)
 	ErrBadRequest                  = NewHTTPError(http.StatusBadRequest)
@@ -58,6 +62,7 @@ var (
 	ErrCookieNotFound              = errors.New("cookie not found")
 	ErrInvalidCertOrKeyType        = errors.New("invalid cert or key type, must be string or []byte")
 	ErrInvalidListenerNetwork      = errors.New("invalid listener network")
+	ErrRouteNotFound               = errors.New("route not found")
 
 	methods = [...]string{
 		http.MethodConnect,
@@ -76,16 +81,52 @@ type (
 	Core struct {
 		sync.Mutex
 
-		Logger      Logger
-		Renderer    Renderer
-		pool        sync.Pool
-		namedRoutes RouteNames
-		routes      RouteList
-		middleware  []MiddlewareFunc
+		Logger     Logger
+		Renderer   Renderer
+		Validator  Validator
+		pool       sync.Pool
+		table      atomic.Value
+		middleware []MiddlewareFunc
+
+		// reloading is set while Reload runs, reloadErr records the
+		// registrations on the serving Core it rejected.
+		reloading bool
+		reloadErr error
+
+		premiddleware []MiddlewareFunc
+		errorPages    map[int]string
 
 		NotFoundHandler         Handler
 		SystemErrorHandler      Handler
 		MethodNotAllowedHandler Handler
+
+		// HTTPErrorHandler handles the errors returned by the handlers,
+		// DefaultHTTPErrorHandler by default.
+		HTTPErrorHandler HTTPErrorHandler
+
+		// Debug answers the errors and panics of the requests from the
+		// local machine with a developer page showing the error chain, the
+		// stack, the route and the request. Never enable it in production.
+		Debug bool
+
+		// ProblemDetails answers the errors as RFC 7807 problem documents
+		// to the clients accepting JSON.
+		ProblemDetails bool
+
+		// DisableImplicitHead stops serving HEAD through GET routes.
+		DisableImplicitHead bool
+
+		// RedirectTrailingSlash redirects /path/ to /path, or the reverse,
+		// when only the other form has a route.
+		RedirectTrailingSlash bool
+
+		// RedirectCleanPath redirects paths with ".", ".." or duplicate
+		// slashes to their cleaned form.
+		RedirectCleanPath bool
+
+		// RedirectCaseInsensitive redirects to the lower-cased path when
+		// only it has a route.
+		RedirectCaseInsensitive bool
 	}
 
 	RouteMatch struct {
@@ -94,6 +135,10 @@ type (
 		PNames   []string
 		PValues  []string
 		MatchErr error
+
+		// AllowedMethods lists the methods registered for the path when
+		// the request method is not allowed or answered automatically.
+		AllowedMethods []string
 	}
 
 	// Handler a responds to an HTTP request
@@ -102,6 +147,9 @@ type (
 	// MiddlewareFunc defines a function to process middleware
 	MiddlewareFunc func(Handler) Handler
 
+	// HTTPErrorHandler handles an error returned while handling a request
+	HTTPErrorHandler func(error, Context)
+
 	// HTTPError an error that occurred while handing a request
 	HTTPError struct {
 		Code    int         `json:"-"`
@@ -118,6 +166,10 @@ const (
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + ";" + charsetUTF8
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + charsetUTF8
+	MIMEApplicationXML                   = "application/xml"
+	MIMEApplicationXMLCharsetUTF8        = MIMEApplicationXML + "; " + charsetUTF8
+	MIMETextXML                          = "text/xml"
+	MIMETextXMLCharsetUTF8               = MIMETextXML + "; " + charsetUTF8
 	MIMEApplicationForm                  = "application/x-www-form-urlencoded"
 	MIMETextHTML                         = "text/html"
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + charsetUTF8
@@ -138,6 +190,20 @@ var (
 		return c.NoContent(http.StatusMethodNotAllowed)
 	}
 
+	// Unmatched requests without handler, answered by the error handler
+	notFoundError = func(Context) error {
+		return ErrNotFound
+	}
+
+	methodNotAllowedError = func(Context) error {
+		return ErrMethodNotAllowed
+	}
+
+	// Default handler options
+	optionsHandler = func(c Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
 	// Default handler error
 	serverErrorHandler = func(c Context) error {
 		return c.NoContent(http.StatusInternalServerError)
@@ -146,9 +212,9 @@ var (
 
 // New returns a Server.
 func Make() *Core {
-	core := &Core{
-		namedRoutes: make(map[string]*Route),
-	}
+	core := &Core{}
+	core.HTTPErrorHandler = core.DefaultHTTPErrorHandler
+	core.table.Store(newRouteTable())
 
 	core.pool.New = func() interface{} {
 		return core.NewContext(nil, nil)
@@ -165,71 +231,138 @@ func (core *Core) SetLog(logger Logger) *Core {
 // NewContext returns a Context instance.
 func (core *Core) NewContext(w http.ResponseWriter, req *http.Request) Context {
 	return &context{
-		request:  req,
-		response: w,
-		renderer: core.Renderer,
-		logger:   core.Logger,
-		body:     make(map[string]interface{}),
+		request:   req,
+		response:  NewResponse(w),
+		renderer:  core.Renderer,
+		validator: core.Validator,
+		logger:    core.Logger,
+		pvalues:   make([]string, 0, core.routeTable().tree.maxParams),
+		body:      make(map[string]interface{}),
 	}
 }
 
-// Use adds middleware
+// Use adds middleware, run after routing for every request, including the
+// not found and method not allowed ones
 func (core *Core) Use(m ...MiddlewareFunc) {
 	core.middleware = append(core.middleware, m...)
 }
 
+// Pre adds middleware run before routing, which may rewrite the request
+func (core *Core) Pre(m ...MiddlewareFunc) {
+	core.premiddleware = append(core.premiddleware, m...)
+}
+
 func (core *Core) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	c := core.pool.Get().(*context)
 	c.Reset(w, rq)
 
+	h := core.handle
+	if len(core.premiddleware) > 0 {
+		h = applyMiddleware(h, core.premiddleware...)
+	}
+
+	if core.Debug {
+		h = debugRecover(h)
+	}
+
+	if err := h(c); err != nil {
+		if core.Debug && debugAllowed(rq) {
+			core.debugErrorHandler(err, c)
+		} else if eh := c.scope.getErrorHandler(); eh != nil {
+			eh(err, c)
+		} else if core.HTTPErrorHandler != nil {
+			core.HTTPErrorHandler(err, c)
+		} else {
+			core.DefaultHTTPErrorHandler(err, c)
+		}
+	}
+
+	core.pool.Put(c)
+}
+
+// handle routes the request of c, after the Pre middleware, and runs the
+// matched handler, the not found or method not allowed one, through the
+// Use middleware.
+func (core *Core) handle(c Context) error {
+	w, rq := c.Response(), c.Request()
+	if core.RedirectCleanPath {
+		if p := getPath(rq); cleanPath(p) != p {
+			return applyMiddleware(redirectHandler(cleanPath(p)), core.middleware...)(c)
+		}
+	}
+
 	var h Handler
-	var match RouteMatch
-	if core.Match(rq, &match) {
+	match := RouteMatch{}
+	if ctx, ok := c.(*context); ok {
+		match.PValues = ctx.pvalues[:0]
+	}
+
+	t := core.routeTable()
+	found := core.match(t, rq, &match)
+	if p, ok := core.redirectPath(t, rq, &match); ok {
+		return applyMiddleware(redirectHandler(p), core.middleware...)(c)
+	}
+
+	if found {
 		h = match.Handler
-		h = applyMiddleware(h, core.middleware...)
 
 		c.SetRoute(match.Route)
 		c.SetParamNames(match.PNames...)
 		c.SetParamValues(match.PValues...)
 	}
 
-	if h == nil && match.MatchErr == ErrMethodNotAllowed {
-		h = methodNotAllowedHandler
-	}
-
-	if h == nil {
-		h = notFoundHandler
-	}
-
-	if err := h(c); err != nil {
-		if err == ErrNotFound {
-			if core.NotFoundHandler != nil {
-				core.NotFoundHandler(c)
-			} else {
-				notFoundHandler(c)
+	// Unmatched requests under a group get its handlers.
+	scope := match.Route.getGroup()
+	if match.Route == nil && match.MatchErr != nil {
+		if scope = t.scope(rq); scope != nil {
+			gh, eh := scope.getNotFound(), notFoundError
+			if match.MatchErr == ErrMethodNotAllowed {
+				gh, eh = scope.getMethodNotAllowed(), methodNotAllowedError
 			}
-		} else {
-			if core.Logger != nil {
-				core.Logger.Error(err)
-			} else {
-				fmt.Println(err)
+
+			// Without a handler for the status the group error handler
+			// answers the error.
+			if gh == nil && scope.getErrorHandler() != nil {
+				gh = eh
 			}
 
-			if core.SystemErrorHandler != nil {
-				core.SystemErrorHandler(c)
-			} else {
-				serverErrorHandler(c)
+			if gh != nil {
+				h = gh
 			}
 		}
 	}
 
-	core.pool.Put(c)
+	if ctx, ok := c.(*context); ok {
+		ctx.scope = scope
+	}
+
+	if len(match.AllowedMethods) > 0 {
+		w.Header().Set(HeaderAllow, strings.Join(match.AllowedMethods, ", "))
+	}
+
+	if h == nil && match.MatchErr == ErrMethodNotAllowed {
+		h = methodNotAllowedError
+	}
+
+	if h == nil {
+		h = notFoundError
+	}
+
+	return applyMiddleware(h, core.middleware...)(c)
 }
 
 // NewRoute create new a Route
 func (core *Core) NewRoute() *Route {
-	route := &Route{namedRoutes: core.namedRoutes, middleware: make([]MiddlewareFunc, 0)}
-	core.routes = append(core.routes, route)
+	// Routes added to the serving Core during Reload are kept out of the
+	// live table.
+	if core.reloading {
+		core.reloadErr = errReloadServing
+		return &Route{namedRoutes: make(RouteNames), middleware: make([]MiddlewareFunc, 0)}
+	}
+
+	t := core.routeTable()
+	route := &Route{namedRoutes: t.namedRoutes, tree: t.tree, middleware: make([]MiddlewareFunc, 0)}
+	t.routes = append(t.routes, route)
 	return route
 }
 
@@ -241,40 +374,86 @@ func (core *Core) Path(path string) *Route {
 	return core.NewRoute().Path(path)
 }
 
-func (core *Core) Match(req *http.Request, match *RouteMatch) bool {
-	for _, route := range core.routes {
-		if route.err != nil || route.reg == nil {
-			continue
-		}
+// GetRoute returns the route registered with the name.
+func (core *Core) GetRoute(name string) *Route {
+	return core.routeTable().namedRoutes[name]
+}
 
-		if matched := route.reg.Math(req); !matched {
-			continue
-		}
+// URL builds the path of a named route, see Route.URL.
+func (core *Core) URL(name string, pairs ...string) (string, error) {
+	route := core.GetRoute(name)
+	if route == nil {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
 
-		if route.method != req.Method {
-			match.MatchErr = ErrMethodNotAllowed
-			continue
+	return route.URL(pairs...)
+}
+
+// Match looks the request up in the route tree. Path params are appended
+// to match.PValues, so a caller may pass a buffer to avoid allocations.
+func (core *Core) Match(req *http.Request, match *RouteMatch) bool {
+	return core.match(core.routeTable(), req, match)
+}
+
+// match looks the request up in the route table t.
+func (core *Core) match(t *routeTable, req *http.Request, match *RouteMatch) bool {
+	tree := t.tree
+	path := getPath(req)
+	l := lookup{req: req, method: req.Method, values: match.PValues[:0]}
+	found := tree.root.find(path, &l)
+
+	// Serve HEAD through the GET route unless a HEAD route matched.
+	head := false
+	if !found && req.Method == http.MethodHead && !core.DisableImplicitHead {
+		get := lookup{req: req, method: http.MethodGet, values: match.PValues[:0]}
+		if found = tree.root.find(path, &get); found {
+			l, head = get, true
 		}
+	}
 
+	if found {
+		route := l.route
 		match.Route = route
 		match.Handler = route.handler
-		match.MatchErr = route.err
+		match.MatchErr = nil
+		match.AllowedMethods = nil
 
 		if len(route.middleware) > 0 {
 			match.Handler = applyMiddleware(match.Handler, route.middleware...)
 		}
 
-		match.PNames = route.reg.VarsN
-
-		path := getPath(req)
-		matches := route.reg.regexp.FindStringSubmatchIndex(path)
-		if len(matches) > 0 {
-			match.PValues = extractVars(path, matches, route.reg.VarsN)
+		if head {
+			match.Handler = implicitHead(match.Handler)
 		}
 
+		match.PNames = route.vars
+		match.PValues = l.values
 		return true
 	}
 
+	// A route with the request method whose matchers failed wins over a
+	// method mismatch on the same path.
+	match.MatchErr = nil
+	if l.mismatch {
+		match.MatchErr = ErrMatcherMismatch
+	} else if l.allowed {
+		match.MatchErr = ErrMethodNotAllowed
+		match.AllowedMethods = l.methods
+		if !core.DisableImplicitHead && InArrayString(l.methods, http.MethodGet) && !InArrayString(l.methods, http.MethodHead) {
+			match.AllowedMethods = append(match.AllowedMethods, http.MethodHead)
+		}
+		if !InArrayString(l.methods, http.MethodOptions) {
+			match.AllowedMethods = append(match.AllowedMethods, http.MethodOptions)
+		}
+
+		// Answer OPTIONS for the path unless the app registered its own.
+		if req.Method == http.MethodOptions {
+			match.Handler = optionsHandler
+			match.MatchErr = nil
+			return true
+		}
+	}
+
 	if match.MatchErr == ErrMethodNotAllowed {
 		if core.MethodNotAllowedHandler != nil {
 			match.Handler = core.MethodNotAllowedHandler
@@ -284,16 +463,58 @@ func (core *Core) Match(req *http.Request, match *RouteMatch) bool {
 		return false
 	}
 
+	if match.MatchErr == nil {
+		match.MatchErr = ErrNotFound
+	}
+
 	if core.NotFoundHandler != nil {
 		match.Handler = core.NotFoundHandler
-		match.MatchErr = ErrNotFound
 		return true
 	}
 
-	match.MatchErr = ErrNotFound
 	return false
 }
 
+// Validate reports every route registration error, duplicate method and
+// path pairs, and routes shadowed by an earlier route.
+func (core *Core) Validate() error {
+	var errs RouteErrors
+	routes := core.routeTable().routes
+	for i, route := range routes {
+		if route.err != nil {
+			errs = append(errs, fmt.Errorf("route %s: %w", route, route.err))
+			continue
+		}
+
+		for _, prev := range routes[:i] {
+			if prev.err != nil {
+				continue
+			}
+
+			if shadow, duplicate := prev.shadows(route); duplicate {
+				errs = append(errs, fmt.Errorf("route %s: duplicate of route %s", route, prev))
+				break
+			} else if shadow {
+				errs = append(errs, fmt.Errorf("route %s: shadowed by route %s", route, prev))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// MustValidate panics if Validate reports an error.
+func (core *Core) MustValidate() {
+	if err := core.Validate(); err != nil {
+		panic(err)
+	}
+}
+
 func (core *Core) GET(path string, h Handler, m ...MiddlewareFunc) *Route {
 	return core.add(http.MethodGet, path, h, m...)
 }
@@ -341,8 +562,7 @@ func (core *Core) Any(path string, h Handler, m ...MiddlewareFunc) []*Route {
 }
 
 func (core *Core) add(method, path string, h Handler, m ...MiddlewareFunc) *Route {
-	h = applyMiddleware(h, m...)
-	return core.Path(path).Handler(h).Method(method)
+	return core.Path(path).Handler(h).Method(method).Use(m...)
 }
 
 func (core *Core) Static(path, root string) *Route {
@@ -406,30 +626,99 @@ func getPath(r *http.Request) string {
 	return path
 }
 
+// DefaultHTTPErrorHandler answers ErrNotFound with the not found handler,
+// validation errors with a 422, an `*HTTPError` with its code and message
+// and any other error with the system error handler, or with the error
+// pages set with ErrorPages for HTML clients. A Problem, or any
+// error with ProblemDetails, is answered as a problem document to clients
+// accepting JSON. Server errors are logged, and nothing is written once
+// the response is committed.
 func (core *Core) DefaultHTTPErrorHandler(err error, c Context) {
-	he, ok := err.(*HTTPError)
-	if !ok {
-		he = &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: http.StatusText(http.StatusInternalServerError),
-		}
+	var he *HTTPError
+	var p *Problem
+	var ve ValidationErrors
+	isHTTPError := errors.As(err, &he)
+	switch {
+	case isHTTPError:
+	case errors.As(err, &p):
+		he, isHTTPError = p.httpError(), true
+	case errors.As(err, &ve):
+		he = NewHTTPError(http.StatusUnprocessableEntity)
+	default:
+		he = ErrInternalServerError
+	}
+
+	if he.Code >= http.StatusInternalServerError {
+		core.logError(err)
+	}
+
+	if c.Response().Committed {
+		return
 	}
 
-	// Issue #1426
-	code := he.Code
-	message := he.Message
-	if m, ok := he.Message.(string); ok {
-		message = Map{"message": m}
+	if core.ProblemDetails || p != nil {
+		if ok, perr := core.problemHandler(err, c); ok {
+			if perr != nil {
+				core.logError(perr)
+			}
+
+			return
+		}
 	}
 
-	if c.Request().Method == http.MethodHead {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		err = core.notFound(c)
+	case ve != nil:
+		err = validationErrorHandler(ve, c)
+	case !isHTTPError && core.SystemErrorHandler != nil:
+		err = core.SystemErrorHandler(c)
+	case core.hasErrorPage(c):
+		err = core.errorPage(c, he.Code, err)
+	case errors.Is(err, ErrMethodNotAllowed):
+		err = methodNotAllowedHandler(c)
+	case !isHTTPError:
+		err = serverErrorHandler(c)
+	case c.Request().Method == http.MethodHead:
 		err = c.NoContent(he.Code)
-	} else {
-		err = c.JSON(code, message)
+	default:
+		// Issue #1426
+		message := he.Message
+		if m, ok := he.Message.(string); ok {
+			message = Map{"message": m}
+		}
+
+		err = c.JSON(he.Code, message)
 	}
 
 	if err != nil {
+		core.logError(err)
+	}
+}
+
+// notFound runs the not found handler of the group of c, of the Core or
+// the default one.
+func (core *Core) notFound(c Context) error {
+	if ctx, ok := c.(*context); ok {
+		if h := ctx.scope.getNotFound(); h != nil {
+			return h(c)
+		}
+	}
+
+	if core.NotFoundHandler != nil {
+		return core.NotFoundHandler(c)
+	}
+
+	return core.pageHandler(http.StatusNotFound, ErrNotFound, notFoundHandler)(c)
+}
+
+// logError logs err through the Logger, or the standard logger when none
+// is set.
+func (core *Core) logError(err error) {
+	if core.Logger != nil {
 		core.Logger.Error(err)
+	} else {
+		log.Println(err)
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -106,3 +107,266 @@ func request(method, path string, core *Core) (int, string) {
 	core.ServeHTTP(rec, req)
 	return rec.Code, rec.Body.String()
 }
+
+func TestOPMAllow(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	r.GET("/users/{id}", h)
+	r.PUT("/users/{id}", h)
+	r.DELETE("/users/{id:[0-9]+}", h)
+	r.GET("/custom", h)
+	r.OPTIONS("/custom", func(c Context) error { return c.String(http.StatusOK, "custom") })
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, PUT, DELETE, HEAD, OPTIONS", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, PUT, DELETE, HEAD, OPTIONS", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodOptions, "/custom", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "custom", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodOptions, "/missing", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(HeaderAllow))
+}
+
+func TestOPMImplicitHead(t *testing.T) {
+	r := Make()
+	r.GET("/page", func(c Context) error {
+		c.Response().Header().Set("X-Page", "1")
+		return c.String(http.StatusOK, "hello")
+	})
+	r.GET("/explicit", func(c Context) error { return c.String(http.StatusOK, "get") })
+	r.HEAD("/explicit", func(c Context) error { return c.NoContent(http.StatusAccepted) })
+	r.POST("/form", func(c Context) error { return nil })
+	r.GET("/missing", func(c Context) error { return ErrNotFound })
+	r.GET("/forbidden", func(c Context) error { return ErrForbidden })
+	r.GET("/failing", func(c Context) error { return errors.New("boom") })
+
+	for path, code := range map[string]int{
+		"/missing":   http.StatusNotFound,
+		"/forbidden": http.StatusForbidden,
+		"/failing":   http.StatusInternalServerError,
+	} {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+			assert.Equal(t, code, rec.Code, method+" "+path)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodHead, "/page", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Page"))
+	assert.Equal(t, "5", rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, 0, rec.Body.Len())
+
+	req = httptest.NewRequest(http.MethodHead, "/explicit", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/page", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodHead, "/form", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	r.DisableImplicitHead = true
+	req = httptest.NewRequest(http.MethodHead, "/page", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestOPMRedirect(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	r.GET("/users", h)
+	r.POST("/users", h)
+	r.GET("/docs/{page:.*/}", h)
+	r.GET("/people/{name}/posts", h)
+
+	testCases := []struct {
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/users/", http.StatusNotFound, ""},
+		{http.MethodGet, "//users/../users", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.target)
+	}
+
+	var seen []string
+	r.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			seen = append(seen, c.Request().URL.Path)
+			return next(c)
+		}
+	})
+
+	r.RedirectTrailingSlash = true
+	r.RedirectCleanPath = true
+	r.RedirectCaseInsensitive = true
+
+	testCases = []struct {
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, ""},
+		{http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users"},
+		{http.MethodGet, "//users/../users", http.StatusMovedPermanently, "/users"},
+		{http.MethodGet, "/./users/", http.StatusMovedPermanently, "/users/"},
+		{http.MethodGet, "/USERS", http.StatusMovedPermanently, "/users"},
+		{http.MethodGet, "/Users/", http.StatusMovedPermanently, "/users"},
+		{http.MethodGet, "/docs/intro", http.StatusMovedPermanently, "/docs/intro/"},
+		{http.MethodGet, "/People/JohnDoe/Posts", http.StatusMovedPermanently, "/people/JohnDoe/posts"},
+		{http.MethodGet, "/people/JohnDoe/posts", http.StatusOK, ""},
+		{http.MethodGet, "/missing/", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.target)
+		assert.Equal(t, tc.location, rec.Header().Get(HeaderLocation), tc.target)
+		assert.Equal(t, []string{req.URL.Path}, seen, tc.target)
+		seen = nil
+	}
+}
+
+func TestOPMPre(t *testing.T) {
+	r := Make()
+	var seen []string
+	r.Pre(func(next Handler) Handler {
+		return func(c Context) error {
+			req := c.Request()
+			if strings.HasPrefix(req.URL.Path, "/v1/") {
+				req.URL.Path = strings.TrimPrefix(req.URL.Path, "/v1")
+			}
+
+			return next(c)
+		}
+	})
+	r.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			seen = append(seen, c.Request().URL.Path)
+			return next(c)
+		}
+	})
+	r.GET("/users", func(c Context) error { return c.String(http.StatusOK, "users") })
+
+	code, body := request(http.MethodGet, "/v1/users", r)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "users", body)
+
+	code, _ = request(http.MethodGet, "/missing", r)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = request(http.MethodPost, "/users", r)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	assert.Equal(t, []string{"/users", "/missing", "/users"}, seen)
+}
+
+type testLogger struct {
+	Logger
+	errors []interface{}
+}
+
+func (l *testLogger) Error(i ...interface{}) {
+	l.errors = append(l.errors, i...)
+}
+
+func TestOPMHTTPErrorHandler(t *testing.T) {
+	r := Make()
+	logger := &testLogger{}
+	r.Logger = logger
+	r.GET("/forbidden", func(Context) error { return ErrForbidden })
+	r.GET("/wrapped", func(Context) error {
+		return fmt.Errorf("create user: %w", NewHTTPError(http.StatusUnprocessableEntity, "invalid"))
+	})
+	r.GET("/unknown", func(Context) error { return errors.New("boom") })
+	r.GET("/unavailable", func(Context) error { return ErrServiceUnavailable })
+	r.GET("/invalid", func(Context) error {
+		return fmt.Errorf("create user: %w", ValidationErrors{newFieldError("name", "required", "", "is required")})
+	})
+	r.GET("/committed", func(c Context) error {
+		c.String(http.StatusAccepted, "done")
+		return errors.New("late")
+	})
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/forbidden", http.StatusForbidden, `{"message":"Forbidden"}`},
+		{"/wrapped", http.StatusUnprocessableEntity, `{"message":"invalid"}`},
+		{"/unknown", http.StatusInternalServerError, ""},
+		{"/unavailable", http.StatusServiceUnavailable, `{"message":"Service Unavailable"}`},
+		{"/invalid", http.StatusUnprocessableEntity,
+			`{"errors":[{"field":"name","rule":"required","message":"is required"}],"message":"Unprocessable Entity"}`},
+		{"/committed", http.StatusAccepted, "done"},
+	}
+
+	for _, tc := range cases {
+		code, body := request(http.MethodGet, tc.path, r)
+		assert.Equal(t, tc.code, code, tc.path)
+		assert.Equal(t, tc.body, body, tc.path)
+	}
+
+	assert.Len(t, logger.errors, 3)
+
+	r.Logger = nil
+	assert.NotPanics(t, func() { request(http.MethodGet, "/unknown", r) })
+
+	var handled error
+	r.HTTPErrorHandler = func(err error, c Context) {
+		handled = err
+		c.NoContent(http.StatusTeapot)
+	}
+
+	code, _ := request(http.MethodGet, "/forbidden", r)
+	assert.Equal(t, http.StatusTeapot, code)
+	assert.Equal(t, ErrForbidden, handled)
+
+	code, _ = request(http.MethodGet, "/nope", r)
+	assert.Equal(t, http.StatusTeapot, code)
+	assert.Equal(t, ErrNotFound, handled)
+
+	req := httptest.NewRequest(http.MethodPost, "/forbidden", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, ErrMethodNotAllowed, handled)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get(HeaderAllow))
+}
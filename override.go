@@ -0,0 +1,89 @@
+package opm
+
+import (
+	sdtContext "context"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type (
+	// MethodOverrideConfig configures the method override of POST requests.
+	MethodOverrideConfig struct {
+		// Header read for the target method, X-HTTP-Method-Override by default.
+		Header string
+
+		// FormField read for the target method, "_method" by default.
+		FormField string
+
+		// Methods a request may be overridden to, PUT, PATCH and DELETE
+		// by default.
+		Methods []string
+	}
+
+	originalMethodKey struct{}
+)
+
+// MethodOverride returns a Pre middleware overriding the method of POST
+// requests before routing, so HTML forms can reach PUT, PATCH and DELETE
+// routes. The form field is only read from form bodies, register the
+// middleware after any body limit.
+func MethodOverride(config MethodOverrideConfig) MiddlewareFunc {
+	if config.Header == "" {
+		config.Header = HeaderXHTTPMethodOverride
+	}
+
+	if config.FormField == "" {
+		config.FormField = "_method"
+	}
+
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	return func(next Handler) Handler {
+		return func(c Context) error {
+			c.SetRequest(config.override(c.Request()))
+			return next(c)
+		}
+	}
+}
+
+// override returns the request with its overridden method, the original
+// method being kept for OriginalMethod.
+func (config *MethodOverrideConfig) override(r *http.Request) *http.Request {
+	if r.Method != http.MethodPost {
+		return r
+	}
+
+	method := r.Header.Get(config.Header)
+	if method == "" && isFormBody(r) {
+		method = r.PostFormValue(config.FormField)
+	}
+
+	method = strings.ToUpper(method)
+	if method == "" || !InArrayString(config.Methods, method) {
+		return r
+	}
+
+	r = r.WithContext(sdtContext.WithValue(r.Context(), originalMethodKey{}, r.Method))
+	r.Method = method
+	return r
+}
+
+// isFormBody reports whether the request body is an URL encoded or
+// multipart form.
+func isFormBody(r *http.Request) bool {
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get(HeaderContentType))
+	return mediatype == MIMEApplicationForm || mediatype == MIMEMultipartForm
+}
+
+// OriginalMethod returns the method of the request before any override.
+// Security checks such as CSRF must consider both methods.
+func OriginalMethod(r *http.Request) string {
+	if method, ok := r.Context().Value(originalMethodKey{}).(string); ok {
+		return method
+	}
+
+	return r.Method
+}
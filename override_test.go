@@ -0,0 +1,67 @@
+package opm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverride(t *testing.T) {
+	r := Make()
+	var limited []string
+	r.Pre(func(next Handler) Handler {
+		return func(c Context) error {
+			limited = append(limited, c.Request().Method)
+			return next(c)
+		}
+	}, MethodOverride(MethodOverrideConfig{}))
+	h := func(c Context) error {
+		return c.String(http.StatusOK, c.Request().Method+" "+OriginalMethod(c.Request()))
+	}
+	r.POST("/users/{id}", h)
+	r.PUT("/users/{id}", h)
+	r.DELETE("/users/{id}", h)
+	r.GET("/users/{id}", h)
+
+	form := url.Values{"_method": {"delete"}}
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(form.Encode()))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "DELETE POST", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set(HeaderXHTTPMethodOverride, "PUT")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "PUT POST", rec.Body.String())
+
+	// Not in the allow-list
+	req = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set(HeaderXHTTPMethodOverride, "GET")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "POST POST", rec.Body.String())
+
+	// Only POST requests are overridden
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(HeaderXHTTPMethodOverride, "DELETE")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "GET GET", rec.Body.String())
+
+	// Middleware registered before the override sees the original method
+	assert.Equal(t, []string{http.MethodPost, http.MethodPost, http.MethodPost, http.MethodGet}, limited)
+
+	// Only form bodies are read
+	req = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`_method=PUT`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "POST POST", rec.Body.String())
+	assert.Nil(t, req.PostForm)
+}
@@ -0,0 +1,50 @@
+package opm
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+var (
+	paramTypesMu sync.RWMutex
+
+	// paramTypes maps the shorthand pattern names usable in routes, e.g.
+	// {id:int}, to their regexp.
+	paramTypes = map[string]string{
+		"int":   `-?[0-9]+`,
+		"uint":  `[0-9]+`,
+		"alpha": `[a-zA-Z]+`,
+		"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+		"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+		"date":  `[0-9]{4}-[0-9]{2}-[0-9]{2}`,
+		"path":  `.*`,
+	}
+)
+
+// RegisterParamType adds a named param type, usable in routes as
+// {name:typ}. It must be called before the routes using it are added.
+func RegisterParamType(typ, pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("param type %s: %w", typ, err)
+	}
+
+	paramTypesMu.Lock()
+	paramTypes[typ] = pattern
+	paramTypesMu.Unlock()
+
+	return nil
+}
+
+// expandPattern returns the regexp of a named param type, or the pattern
+// itself.
+func expandPattern(pattern string) string {
+	paramTypesMu.RLock()
+	defer paramTypesMu.RUnlock()
+
+	if p, ok := paramTypes[pattern]; ok {
+		return p
+	}
+
+	return pattern
+}
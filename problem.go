@@ -0,0 +1,183 @@
+package opm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// MIMEApplicationProblemJSON is the content type of RFC 7807 problem
+// documents.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemOffers are the content types negotiated by the error handler,
+// problem documents being sent to the JSON ones only.
+var problemOffers = []string{MIMEApplicationProblemJSON, MIMEApplicationJSON, MIMETextHTML, MIMETextPlain}
+
+// Problem is an error answered as an RFC 7807 problem document. The
+// Extensions are encoded as members of the document.
+type Problem struct {
+	Type       string                 `json:"type,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Status     int                    `json:"status,omitempty"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// NewProblem returns a Problem titled by the status text.
+func NewProblem(status int, detail ...string) *Problem {
+	p := &Problem{Status: status, Title: http.StatusText(status)}
+	if len(detail) > 0 {
+		p.Detail = detail[0]
+	}
+
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return StrConcat(p.Title, ": ", p.Detail)
+	}
+
+	return p.Title
+}
+
+// With sets an extension member.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// ProblemOf returns the problem document of err: a Problem as is, an
+// HTTPError with its code and message, validation errors as a 422 with
+// their fields, and a 500 without detail for any other error.
+func ProblemOf(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		return NewProblem(http.StatusUnprocessableEntity).With("errors", ve)
+	}
+
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		return NewProblem(http.StatusInternalServerError)
+	}
+
+	p = NewProblem(he.Code)
+	switch m := he.Message.(type) {
+	case string:
+		if m != p.Title {
+			p.Detail = m
+		}
+	case nil:
+	default:
+		p.With("message", m)
+	}
+
+	return p
+}
+
+// httpError returns the HTTPError with the status and detail of p, a 500
+// when p has no status.
+func (p *Problem) httpError() *HTTPError {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	if p.Detail != "" {
+		return NewHTTPError(status, p.Detail)
+	}
+
+	return NewHTTPError(status)
+}
+
+// problemHandler answers err as a problem document when the client
+// accepts JSON, and reports whether it did.
+func (core *Core) problemHandler(err error, c Context) (bool, error) {
+	addVary(c.Response().Header(), HeaderAccept)
+	contentType := acceptedType(c.Request(), problemOffers...)
+	if contentType != MIMEApplicationProblemJSON && contentType != MIMEApplicationJSON {
+		return false, nil
+	}
+
+	p := *ProblemOf(err)
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+
+	if p.Instance == "" {
+		p.Instance = c.Request().URL.RequestURI()
+	}
+
+	if id := requestID(c); id != "" {
+		if _, ok := p.Extensions["request_id"]; !ok {
+			ext := make(map[string]interface{}, len(p.Extensions)+1)
+			for k, v := range p.Extensions {
+				ext[k] = v
+			}
+
+			ext["request_id"] = id
+			p.Extensions = ext
+		}
+	}
+
+	if c.Request().Method == http.MethodHead {
+		return true, c.NoContent(p.Status)
+	}
+
+	b, err := json.Marshal(&p)
+	if err != nil {
+		return true, err
+	}
+
+	return true, c.Blob(p.Status, contentType, b)
+}
+
+// requestID returns the request ID set on the response, or sent by the
+// client.
+func requestID(c Context) string {
+	if id := c.Response().Header().Get(HeaderXRequestID); id != "" {
+		return id
+	}
+
+	return c.Request().Header.Get(HeaderXRequestID)
+}
@@ -0,0 +1,97 @@
+package opm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	p := NewProblem(http.StatusConflict, "email taken").With("field", "email")
+	p.Type = "https://example.com/probs/conflict"
+
+	b, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "https://example.com/probs/conflict",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "email taken",
+		"field": "email"
+	}`, string(b))
+	assert.Equal(t, "Conflict: email taken", p.Error())
+
+	assert.Equal(t, NewProblem(http.StatusForbidden), ProblemOf(ErrForbidden))
+	assert.Equal(t, NewProblem(http.StatusBadRequest, "bad id"), ProblemOf(NewHTTPError(http.StatusBadRequest, "bad id")))
+	assert.Equal(t, NewProblem(http.StatusInternalServerError), ProblemOf(errors.New("secret")))
+	assert.Equal(t, http.StatusUnprocessableEntity, ProblemOf(ValidationErrors{{Field: "name"}}).Status)
+}
+
+func TestOPMProblemDetails(t *testing.T) {
+	r := Make()
+	r.ProblemDetails = true
+	r.GET("/forbidden", func(Context) error { return ErrForbidden })
+	r.GET("/unknown", func(Context) error { return errors.New("secret") })
+
+	cases := []struct {
+		path, accept, contentType string
+		code                      int
+		body                      string
+	}{
+		{"/forbidden?a=1", "", MIMEApplicationProblemJSON, http.StatusForbidden,
+			`{"title":"Forbidden","status":403,"instance":"/forbidden?a=1","request_id":"abc"}`},
+		{"/unknown", MIMEApplicationJSON, MIMEApplicationJSON, http.StatusInternalServerError,
+			`{"title":"Internal Server Error","status":500,"instance":"/unknown","request_id":"abc"}`},
+		{"/forbidden", "text/html,*/*;q=0.8", MIMEApplicationJSONCharsetUTF8, http.StatusForbidden,
+			`{"message":"Forbidden"}`},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set(HeaderXRequestID, "abc")
+		if tc.accept != "" {
+			req.Header.Set(HeaderAccept, tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+		assert.Equal(t, tc.contentType, rec.Header().Get(HeaderContentType), tc.path)
+		assert.Equal(t, HeaderAccept, rec.Header().Get(HeaderVary), tc.path)
+		assert.JSONEq(t, tc.body, rec.Body.String(), tc.path)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, MIMEApplicationJSON, rec.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{"title":"Not Found","status":404,"instance":"/missing"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/forbidden", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get(HeaderAllow))
+	assert.JSONEq(t, `{"title":"Method Not Allowed","status":405,"instance":"/forbidden"}`, rec.Body.String())
+
+	r = Make()
+	r.GET("/conflict", func(Context) error { return NewProblem(http.StatusConflict, "taken") })
+	code, body := request(http.MethodGet, "/conflict", r)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.JSONEq(t, `{"title":"Conflict","status":409,"detail":"taken","instance":"/conflict"}`, body)
+
+	r.GET("/nostatus", func(Context) error { return &Problem{Title: "Oops"} })
+	req = httptest.NewRequest(http.MethodGet, "/nostatus", nil)
+	req.Header.Set(HeaderAccept, MIMETextHTML)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"message":"Internal Server Error"}`, rec.Body.String())
+}
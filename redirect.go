@@ -0,0 +1,95 @@
+package opm
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// cleanPath returns the canonical form of p: "." and ".." elements and
+// duplicate slashes removed, the trailing slash kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	cp := path.Clean("/" + p)
+	if lastChar(p) == '/' && cp != "/" {
+		cp += "/"
+	}
+
+	return cp
+}
+
+// redirectPath returns the path a request without route in the table t
+// should be redirected to according to the trailing slash and case
+// policies.
+func (core *Core) redirectPath(t *routeTable, req *http.Request, match *RouteMatch) (string, bool) {
+	p := getPath(req)
+	if match.MatchErr != ErrNotFound || !(core.RedirectTrailingSlash || core.RedirectCaseInsensitive) {
+		return "", false
+	}
+
+	var candidates []string
+	if core.RedirectTrailingSlash && p != "/" {
+		if strings.HasSuffix(p, "/") {
+			candidates = append(candidates, strings.TrimSuffix(p, "/"))
+		} else {
+			candidates = append(candidates, p+"/")
+		}
+	}
+
+	for _, c := range candidates {
+		if t.pathExists(req, c) {
+			return c, true
+		}
+	}
+
+	if core.RedirectCaseInsensitive {
+		for _, c := range append([]string{p}, candidates...) {
+			if fp, ok := t.foldPath(req, c); ok && fp != c {
+				return fp, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// foldPath returns the path of the route matching p without case, with
+// the static text as registered and the param values kept.
+func (t *routeTable) foldPath(req *http.Request, p string) (string, bool) {
+	l := lookup{req: req, method: req.Method}
+	canon, ok := t.tree.root.findFold(p, &l, make([]byte, 0, len(p)))
+	return string(canon), ok
+}
+
+// pathExists reports whether a route is registered for the path, whatever
+// its method.
+func (t *routeTable) pathExists(req *http.Request, p string) bool {
+	l := lookup{req: req, method: req.Method}
+	return t.tree.root.find(p, &l) || l.allowed || l.mismatch
+}
+
+// redirect sends the client to p, keeping the query. GET and HEAD use 301,
+// other methods 308 so the method and body are kept.
+func redirect(w http.ResponseWriter, req *http.Request, p string) {
+	code := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	if req.URL.RawQuery != "" {
+		p = StrConcat(p, "?", req.URL.RawQuery)
+	}
+
+	http.Redirect(w, req, p, code)
+}
+
+// redirectHandler sends the client to p, see redirect.
+func redirectHandler(p string) Handler {
+	return func(c Context) error {
+		redirect(c.Response(), c.Request(), p)
+		return nil
+	}
+}
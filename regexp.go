@@ -4,26 +4,50 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+type regexpType int
+
+const (
+	regexpTypePath regexpType = iota
+	regexpTypeHost
+	regexpTypeQuery
+)
+
 type routeRegexp struct {
-	regexp  *regexp.Regexp
-	reverse string
-	VarsN   []string
-	VarsR   []*regexp.Regexp
+	template string
+	typ      regexpType
+	regexp   *regexp.Regexp
+	reverse  string
+	VarsN    []string
+	VarsR    []*regexp.Regexp
+
+	// withPort is set when the static text of a host template has a port.
+	withPort bool
 }
 
-func newRouteRegexp(path string) (*routeRegexp, error) {
-	path = strings.TrimSuffix(path, "/")
-	if path == "" {
-		path = "/"
+func newRouteRegexp(path string, typ regexpType) (*routeRegexp, error) {
+	defaultPattern := "[^/]+"
+	switch typ {
+	case regexpTypeHost:
+		defaultPattern = "[^.]+"
+	case regexpTypeQuery:
+		defaultPattern = ".*"
 	}
 
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+	if typ == regexpTypePath {
+		path = strings.TrimSuffix(path, "/")
+		if path == "" {
+			path = "/"
+		}
+
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
 	}
 
 	idxs, err := braceIndices(path)
@@ -31,8 +55,6 @@ func newRouteRegexp(path string) (*routeRegexp, error) {
 		return nil, err
 	}
 
-	defaultPattern := "[^/]+"
-
 	varsN := make([]string, len(idxs)/2)
 	varsR := make([]*regexp.Regexp, len(idxs)/2)
 
@@ -40,15 +62,17 @@ func newRouteRegexp(path string) (*routeRegexp, error) {
 	pattern.WriteByte('^')
 	reverse := bytes.NewBufferString("")
 
+	var withPort bool
 	var end int
 	for i := 0; i < len(idxs); i += 2 {
 		raw := path[end:idxs[i]]
+		withPort = withPort || strings.Contains(raw, ":")
 		end = idxs[i+1]
 		parts := strings.SplitN(path[idxs[i]+1:end-1], ":", 2)
 		name := parts[0]
 		patt := defaultPattern
 		if len(parts) == 2 {
-			patt = parts[1]
+			patt = expandPattern(parts[1])
 		}
 
 		if name == "" || patt == "" {
@@ -57,20 +81,21 @@ func newRouteRegexp(path string) (*routeRegexp, error) {
 
 		gn := "v" + strconv.Itoa(i/2)
 		fmt.Fprintf(pattern, "%s(?P<%s>%s)", regexp.QuoteMeta(raw), gn, patt)
-		fmt.Fprintf(reverse, "%s%%s", raw)
+		fmt.Fprintf(reverse, "%s%%s", strings.ReplaceAll(raw, "%", "%%"))
 
 		varsN[i/2] = name
-		varsR[i/2], err = regexp.Compile(fmt.Sprintf("^%s$", patt))
+		varsR[i/2], err = regexp.Compile(fmt.Sprintf("^(?:%s)$", patt))
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	raw := path[end:]
+	withPort = withPort || strings.Contains(raw, ":")
 	pattern.WriteString(regexp.QuoteMeta(raw))
 	pattern.WriteByte('$')
 
-	reverse.WriteString(raw)
+	reverse.WriteString(strings.ReplaceAll(raw, "%", "%%"))
 
 	reg, err := regexp.Compile(pattern.String())
 	if err != nil {
@@ -78,22 +103,131 @@ func newRouteRegexp(path string) (*routeRegexp, error) {
 	}
 
 	if reg.NumSubexp() != len(idxs)/2 {
-		panic(fmt.Sprintf("route %s contains capture groups in its regexp.", path))
+		return nil, fmt.Errorf("route %s contains capture groups in its regexp", path)
 	}
 
 	return &routeRegexp{
-		regexp:  reg,
-		reverse: reverse.String(),
-		VarsN:   varsN,
-		VarsR:   varsR,
+		template: path,
+		typ:      typ,
+		regexp:   reg,
+		reverse:  reverse.String(),
+		VarsN:    varsN,
+		VarsR:    varsR,
+		withPort: typ == regexpTypeHost && withPort,
 	}, nil
 }
 
+// shape returns the template with its variables replaced by "{}" and the
+// pattern of each variable, an empty pattern being the default one.
+func (r *routeRegexp) shape() (string, []string) {
+	idxs, _ := braceIndices(r.template)
+	patterns := make([]string, len(idxs)/2)
+	shape := bytes.NewBufferString("")
+
+	var end int
+	for i := 0; i < len(idxs); i += 2 {
+		shape.WriteString(r.template[end:idxs[i]])
+		shape.WriteString("{}")
+		end = idxs[i+1]
+		if parts := strings.SplitN(r.template[idxs[i]+1:end-1], ":", 2); len(parts) == 2 {
+			patterns[i/2] = expandPattern(parts[1])
+		}
+	}
+
+	shape.WriteString(r.template[end:])
+	return shape.String(), patterns
+}
+
+// url builds a path from the reverse template. Pairs whose key is not a
+// route variable are encoded as query parameters.
+func (r *routeRegexp) url(pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("number of parameters must be multiple of 2, got %v", pairs)
+	}
+
+	values := make([]interface{}, len(r.VarsN))
+	query := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		k := -1
+		for j, name := range r.VarsN {
+			if name == pairs[i] {
+				k = j
+				break
+			}
+		}
+
+		if k < 0 {
+			query.Add(pairs[i], pairs[i+1])
+			continue
+		}
+
+		if !r.VarsR[k].MatchString(pairs[i+1]) {
+			return "", fmt.Errorf("variable %q doesn't match, expected %q", pairs[i+1], r.VarsR[k].String())
+		}
+
+		values[k] = escapePath(pairs[i+1])
+	}
+
+	for i, v := range values {
+		if v == nil {
+			return "", fmt.Errorf("missing route variable %q", r.VarsN[i])
+		}
+	}
+
+	path := fmt.Sprintf(r.reverse, values...)
+	if len(query) > 0 {
+		path = StrConcat(path, "?", query.Encode())
+	}
+
+	return path, nil
+}
+
 func (r *routeRegexp) Math(req *http.Request) bool {
+	if r.typ == regexpTypeHost {
+		return r.regexp.MatchString(r.getHost(req))
+	}
+
 	var path = getPath(req)
 	return r.regexp.MatchString(path)
 }
 
+// getHost returns the request host, without the port unless the host
+// template has one.
+func (r *routeRegexp) getHost(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	if !r.withPort {
+		if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+			host = host[:i]
+		}
+	}
+
+	return host
+}
+
+// setValues appends the template variable values found in s.
+func (r *routeRegexp) setValues(s string, values []string) []string {
+	matches := r.regexp.FindStringSubmatchIndex(s)
+	for i := range r.VarsN {
+		values = append(values, s[matches[2*i+2]:matches[2*i+3]])
+	}
+
+	return values
+}
+
+// escapePath escapes each segment of the value, keeping the slashes.
+func escapePath(s string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+
+	return strings.Join(segments, "/")
+}
+
 func braceIndices(s string) ([]int, error) {
 	var level, idx int
 	var idxs []int
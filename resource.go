@@ -0,0 +1,211 @@
+package opm
+
+import (
+	"net/http"
+	"strings"
+)
+
+type (
+	// ResourceIndexer lists the resources: GET /users
+	ResourceIndexer interface {
+		Index(Context) error
+	}
+
+	// ResourceCreator shows the creation form: GET /users/create
+	ResourceCreator interface {
+		Create(Context) error
+	}
+
+	// ResourceStorer creates a resource: POST /users
+	ResourceStorer interface {
+		Store(Context) error
+	}
+
+	// ResourceShower shows a resource: GET /users/{user}
+	ResourceShower interface {
+		Show(Context) error
+	}
+
+	// ResourceEditor shows the edition form: GET /users/{user}/edit
+	ResourceEditor interface {
+		Edit(Context) error
+	}
+
+	// ResourceUpdater updates a resource: PUT and PATCH /users/{user}
+	ResourceUpdater interface {
+		Update(Context) error
+	}
+
+	// ResourceDestroyer deletes a resource: DELETE /users/{user}
+	ResourceDestroyer interface {
+		Destroy(Context) error
+	}
+
+	// Resource holds the routes registered for a resource controller.
+	Resource struct {
+		Routes []*Route
+
+		name  string
+		path  string
+		param string
+		add   func(method, path string, h Handler, m ...MiddlewareFunc) *Route
+	}
+
+	// ResourceOption configures the routes of a resource.
+	ResourceOption func(*resourceConfig)
+
+	resourceConfig struct {
+		only   []string
+		except []string
+		param  string
+		name   string
+		m      []MiddlewareFunc
+	}
+)
+
+// Only registers the given actions only, e.g. Only("index", "show").
+func Only(actions ...string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.only = append(c.only, actions...)
+	}
+}
+
+// Except registers every action but the given ones.
+func Except(actions ...string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.except = append(c.except, actions...)
+	}
+}
+
+// ResourceParam sets the name of the path param, the singular of the
+// resource name by default: "user" for "/users".
+func ResourceParam(name string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.param = name
+	}
+}
+
+// ResourceName sets the route name prefix, built from the path by
+// default: "users" for "/users", "users.posts" for "/users/{user}/posts".
+func ResourceName(name string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.name = name
+	}
+}
+
+// ResourceMiddleware adds middleware to every route of the resource.
+func ResourceMiddleware(m ...MiddlewareFunc) ResourceOption {
+	return func(c *resourceConfig) {
+		c.m = append(c.m, m...)
+	}
+}
+
+// Resource registers the RESTful routes of the actions ctrl implements,
+// named like "users.show".
+func (core *Core) Resource(path string, ctrl interface{}, opts ...ResourceOption) *Resource {
+	return newResource(core.add, path, ctrl, opts...)
+}
+
+// Resource registers the RESTful routes of the actions ctrl implements
+// under the group prefix.
+func (g *Group) Resource(path string, ctrl interface{}, opts ...ResourceOption) *Resource {
+	return newResource(g.add, path, ctrl, opts...)
+}
+
+// Resource registers a resource nested under the current one, e.g.
+// core.Resource("/users", u).Resource("posts", p) for /users/{user}/posts.
+func (res *Resource) Resource(path string, ctrl interface{}, opts ...ResourceOption) *Resource {
+	opts = append([]ResourceOption{ResourceName(StrConcat(res.name, ".", resourceName(path)))}, opts...)
+	path = joinPaths(StrConcat(res.path, "/{", res.param, "}"), path)
+	return newResource(res.add, path, ctrl, opts...)
+}
+
+func newResource(add func(string, string, Handler, ...MiddlewareFunc) *Route, path string, ctrl interface{}, opts ...ResourceOption) *Resource {
+	path = strings.TrimSuffix(path, "/")
+	config := &resourceConfig{name: resourceName(path)}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.param == "" {
+		config.param = resourceParam(path)
+	}
+
+	res := &Resource{name: config.name, path: path, param: config.param, add: add}
+	item := StrConcat(path, "/{", config.param, "}")
+
+	route := func(action, method, p string, h Handler) {
+		if len(config.only) > 0 && !InArrayString(config.only, action) {
+			return
+		}
+
+		if InArrayString(config.except, action) {
+			return
+		}
+
+		r := add(method, p, h, config.m...)
+		if method != http.MethodPatch {
+			r.Name(StrConcat(config.name, ".", action))
+		}
+
+		res.Routes = append(res.Routes, r)
+	}
+
+	if c, ok := ctrl.(ResourceIndexer); ok {
+		route("index", http.MethodGet, path, c.Index)
+	}
+
+	if c, ok := ctrl.(ResourceCreator); ok {
+		route("create", http.MethodGet, StrConcat(path, "/create"), c.Create)
+	}
+
+	if c, ok := ctrl.(ResourceStorer); ok {
+		route("store", http.MethodPost, path, c.Store)
+	}
+
+	if c, ok := ctrl.(ResourceShower); ok {
+		route("show", http.MethodGet, item, c.Show)
+	}
+
+	if c, ok := ctrl.(ResourceEditor); ok {
+		route("edit", http.MethodGet, StrConcat(item, "/edit"), c.Edit)
+	}
+
+	if c, ok := ctrl.(ResourceUpdater); ok {
+		route("update", http.MethodPut, item, c.Update)
+		route("update", http.MethodPatch, item, c.Update)
+	}
+
+	if c, ok := ctrl.(ResourceDestroyer); ok {
+		route("destroy", http.MethodDelete, item, c.Destroy)
+	}
+
+	return res
+}
+
+// resourceName joins the static segments of the path with dots.
+func resourceName(path string) string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" && !strings.HasPrefix(seg, "{") {
+			names = append(names, seg)
+		}
+	}
+
+	return strings.Join(names, ".")
+}
+
+// resourceParam returns the singular of the last path segment.
+func resourceParam(path string) string {
+	name := path[strings.LastIndexByte(path, '/')+1:]
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return StrConcat(strings.TrimSuffix(name, "ies"), "y")
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	case name == "" || strings.HasPrefix(name, "{"):
+		return "id"
+	}
+
+	return name
+}
@@ -0,0 +1,74 @@
+package opm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userController struct{}
+
+func (userController) Index(c Context) error { return c.String(http.StatusOK, "index") }
+func (userController) Store(c Context) error { return c.String(http.StatusOK, "store") }
+func (userController) Show(c Context) error  { return c.String(http.StatusOK, "show "+c.Param("user")) }
+func (userController) Update(c Context) error {
+	return c.String(http.StatusOK, "update "+c.Param("user"))
+}
+func (userController) Destroy(c Context) error { return c.String(http.StatusOK, "destroy") }
+
+type postController struct{}
+
+func (postController) Index(c Context) error {
+	return c.String(http.StatusOK, "posts "+c.Param("user"))
+}
+func (postController) Show(c Context) error {
+	return c.String(http.StatusOK, "post "+c.Param("user")+" "+c.Param("post"))
+}
+
+func TestResource(t *testing.T) {
+	r := Make()
+	users := r.Resource("/users", userController{}, Except("destroy"))
+	users.Resource("posts", postController{})
+	r.Group("/admin").Resource("/categories", userController{}, Only("index", "show"), ResourceParam("user"))
+
+	testCases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, "index"},
+		{http.MethodPost, "/users", http.StatusOK, "store"},
+		{http.MethodGet, "/users/7", http.StatusOK, "show 7"},
+		{http.MethodPut, "/users/7", http.StatusOK, "update 7"},
+		{http.MethodPatch, "/users/7", http.StatusOK, "update 7"},
+		{http.MethodDelete, "/users/7", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/users/create", http.StatusOK, "show create"},
+		{http.MethodGet, "/users/7/posts", http.StatusOK, "posts 7"},
+		{http.MethodGet, "/users/7/posts/3", http.StatusOK, "post 7 3"},
+		{http.MethodGet, "/admin/categories/2", http.StatusOK, "show 2"},
+		{http.MethodPost, "/admin/categories", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tc := range testCases {
+		code, body := request(tc.method, tc.path, r)
+		assert.Equal(t, tc.code, code, tc.path)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, tc.body, body, tc.path)
+		}
+	}
+
+	u, err := r.URL("users.posts.show", "user", "7", "post", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/7/posts/3", u)
+
+	for _, name := range []string{"users.index", "users.store", "users.show", "users.update", "categories.index", "categories.show"} {
+		assert.NotNil(t, r.GetRoute(name), name)
+	}
+
+	assert.Nil(t, r.GetRoute("users.destroy"))
+	assert.Nil(t, r.GetRoute("users.edit"))
+	assert.Len(t, users.Routes, 5)
+	assert.NoError(t, r.Validate())
+}
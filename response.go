@@ -0,0 +1,130 @@
+package opm
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Response wraps an `http.ResponseWriter` to record the status code and
+// the size of the response for middleware. Status is 200 until the header
+// is written, once, later calls to WriteHeader being ignored.
+type Response struct {
+	Writer    http.ResponseWriter
+	Status    int
+	Size      int64
+	Committed bool
+
+	beforeFuncs []func()
+	afterFuncs  []func()
+}
+
+var errHijackNotSupported = errors.New("response writer does not implement http.Hijacker")
+
+// NewResponse returns a Response writing to w.
+func NewResponse(w http.ResponseWriter) *Response {
+	return &Response{Writer: w, Status: http.StatusOK}
+}
+
+// Header returns the header map of the writer.
+func (r *Response) Header() http.Header {
+	return r.Writer.Header()
+}
+
+// Before registers a function run just before the header is written.
+func (r *Response) Before(fn func()) {
+	r.beforeFuncs = append(r.beforeFuncs, fn)
+}
+
+// After registers a function run after each write of the body.
+func (r *Response) After(fn func()) {
+	r.afterFuncs = append(r.afterFuncs, fn)
+}
+
+// WriteHeader sends the header with the status code, once.
+func (r *Response) WriteHeader(code int) {
+	if r.Committed {
+		return
+	}
+
+	r.Status = code
+	for _, fn := range r.beforeFuncs {
+		fn()
+	}
+
+	r.Writer.WriteHeader(r.Status)
+	r.Committed = true
+}
+
+// Write writes the body, sending a 200 header first if none was written.
+func (r *Response) Write(b []byte) (n int, err error) {
+	if !r.Committed {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	n, err = r.Writer.Write(b)
+	r.Size += int64(n)
+	for _, fn := range r.afterFuncs {
+		fn()
+	}
+
+	return
+}
+
+// ReadFrom copies the body from src, through the writer's io.ReaderFrom
+// when it has one.
+func (r *Response) ReadFrom(src io.Reader) (n int64, err error) {
+	if !r.Committed {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if rf, ok := r.Writer.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{r.Writer}, src)
+	}
+
+	r.Size += n
+	for _, fn := range r.afterFuncs {
+		fn()
+	}
+
+	return
+}
+
+// Flush sends the buffered data to the client when the writer supports it.
+func (r *Response) Flush() {
+	if !r.Committed {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := r.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.Writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackNotSupported
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer, for `http.ResponseController`.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.Writer
+}
+
+func (r *Response) reset(w http.ResponseWriter) {
+	r.Writer = w
+	r.Status = http.StatusOK
+	r.Size = 0
+	r.Committed = false
+	r.beforeFuncs = nil
+	r.afterFuncs = nil
+}
@@ -0,0 +1,82 @@
+package opm
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+
+	var calls []string
+	res.Before(func() {
+		calls = append(calls, "before")
+		res.Header().Set("X-Before", "1")
+	})
+	res.After(func() { calls = append(calls, "after") })
+
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.False(t, res.Committed)
+
+	res.WriteHeader(http.StatusCreated)
+	res.WriteHeader(http.StatusTeapot)
+	res.Write([]byte("hello"))
+
+	assert.True(t, res.Committed)
+	assert.Equal(t, http.StatusCreated, res.Status)
+	assert.Equal(t, int64(5), res.Size)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Before"))
+	assert.Equal(t, []string{"before", "after"}, calls)
+
+	n, err := res.ReadFrom(strings.NewReader(" world"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), n)
+	assert.Equal(t, int64(11), res.Size)
+	assert.Equal(t, "hello world", rec.Body.String())
+
+	res.Flush()
+	assert.True(t, rec.Flushed)
+
+	_, _, err = res.Hijack()
+	assert.Equal(t, errHijackNotSupported, err)
+
+	_, _, err = NewResponse(hijackRecorder{rec}).Hijack()
+	assert.NoError(t, err)
+}
+
+func TestContextResponse(t *testing.T) {
+	r := Make()
+	r.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			err := next(c)
+			assert.Equal(t, http.StatusAccepted, c.Response().Status)
+			assert.Equal(t, int64(2), c.Response().Size)
+			return err
+		}
+	})
+	r.GET("/", func(c Context) error {
+		c.String(http.StatusAccepted, "ok")
+		return c.String(http.StatusOK, "")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
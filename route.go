@@ -1,6 +1,11 @@
 package opm
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 type (
 	Route struct {
@@ -10,14 +15,38 @@ type (
 		err         error
 		handler     Handler
 		reg         *routeRegexp
+		host        *routeRegexp
+		queries     []*queryMatcher
+		matchers    []Matcher
+		vars        []string
+		meta        map[string]interface{}
+		tags        []string
+		summary     string
+		deprecated  bool
 		middleware  []MiddlewareFunc
+		group       *Group
+		namePrefix  string
 		namedRoutes map[string]*Route
+		tree        *routeTree
+		leaf        *node
 	}
 
 	RouteList  []*Route
 	RouteNames map[string]*Route
+
+	// RouteErrors collects the errors found by Core.Validate.
+	RouteErrors []error
 )
 
+func (e RouteErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
 // Method sets type method `*Route`
 func (r *Route) Method(method string) *Route {
 	r.method = method
@@ -34,6 +63,7 @@ func (r *Route) GetName() string {
 }
 
 func (r *Route) Name(name string) *Route {
+	name = StrConcat(r.namePrefix, name)
 	if r.namedRoutes[name] != nil {
 		r.err = fmt.Errorf("route already has name %s", name)
 	}
@@ -45,12 +75,30 @@ func (r *Route) Name(name string) *Route {
 	return r
 }
 
+// getGroup returns the group the route was added with.
+func (r *Route) getGroup() *Group {
+	if r == nil {
+		return nil
+	}
+
+	return r.group
+}
+
 func (r *Route) GetPath() string {
 	return r.path
 }
 
 func (r *Route) Path(path string) *Route {
-	rr, err := newRouteRegexp(path)
+	if r.leaf != nil {
+		r.tree.remove(r.leaf, r)
+		r.leaf = nil
+	}
+
+	rr, err := newRouteRegexp(path, regexpTypePath)
+	if err == nil && r.tree != nil {
+		r.leaf, err = r.tree.insert(rr.template, r)
+	}
+
 	r.reg = rr
 	r.err = err
 	r.path = path
@@ -58,9 +106,235 @@ func (r *Route) Path(path string) *Route {
 		r.name = path
 	}
 
+	r.setVars()
+	return r
+}
+
+// Host restricts the route to a host template, e.g. "{tenant}.example.com".
+// The port is only matched when the template has one.
+func (r *Route) Host(host string) *Route {
+	rr, err := newRouteRegexp(host, regexpTypeHost)
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	r.host = rr
+	r.setVars()
+	return r
+}
+
+// Headers adds a matcher for header key/value pairs. An empty value only
+// requires the header to be present.
+func (r *Route) Headers(pairs ...string) *Route {
+	if len(pairs)%2 != 0 {
+		r.err = fmt.Errorf("number of parameters must be multiple of 2, got %v", pairs)
+		return r
+	}
+
+	m := make(headerMatcher, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		m[http.CanonicalHeaderKey(pairs[i])] = pairs[i+1]
+	}
+
+	return r.AddMatcher(m)
+}
+
+// Queries adds a matcher for query key/value pairs. Values may be
+// templates, e.g. Queries("format", "{fmt:csv|json}"), whose variables
+// are read through Context.Param.
+func (r *Route) Queries(pairs ...string) *Route {
+	if len(pairs)%2 != 0 {
+		r.err = fmt.Errorf("number of parameters must be multiple of 2, got %v", pairs)
+		return r
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		m, err := newQueryMatcher(pairs[i], pairs[i+1])
+		if err != nil {
+			r.err = err
+			return r
+		}
+
+		r.queries = append(r.queries, m)
+		r.AddMatcher(m)
+	}
+
+	r.setVars()
+	return r
+}
+
+// Schemes adds a matcher for the request scheme, e.g. "https". The scheme
+// is the one of the request URL or connection, behind a proxy a Pre
+// middleware may set req.URL.Scheme from the headers it trusts.
+func (r *Route) Schemes(schemes ...string) *Route {
+	m := make(schemeMatcher, len(schemes))
+	for i, s := range schemes {
+		m[i] = strings.ToLower(s)
+	}
+
+	return r.AddMatcher(m)
+}
+
+// MatcherFunc adds a custom matcher function.
+func (r *Route) MatcherFunc(f func(*http.Request) bool) *Route {
+	return r.AddMatcher(MatcherFunc(f))
+}
+
+// AddMatcher adds a condition the request must meet.
+func (r *Route) AddMatcher(m Matcher) *Route {
+	r.matchers = append(r.matchers, m)
+	return r
+}
+
+func (r *Route) match(req *http.Request) bool {
+	for _, m := range r.matchers {
+		if !m.Match(req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setVars collects the variable names: path, host then query variables.
+func (r *Route) setVars() {
+	r.vars = nil
+	if r.reg != nil {
+		r.vars = append(r.vars, r.reg.VarsN...)
+	}
+
+	if r.host != nil {
+		r.vars = append(r.vars, r.host.VarsN...)
+	}
+
+	for _, q := range r.queries {
+		r.vars = append(r.vars, q.reg.VarsN...)
+	}
+}
+
+// setValues appends the host and query variable values.
+func (r *Route) setValues(req *http.Request, values []string) []string {
+	if r.host != nil {
+		values = r.host.setValues(r.host.getHost(req), values)
+	}
+
+	for _, q := range r.queries {
+		values = q.setValues(req, values)
+	}
+
+	return values
+}
+
+// URL builds the path of the route from variable name/value pairs, e.g.
+// URL("id", "42"). Pairs that are not route variables become the query.
+func (r *Route) URL(pairs ...string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	if r.reg == nil {
+		return "", errors.New("route has no path")
+	}
+
+	if r.host != nil {
+		pairs = skipVars(pairs, r.host.VarsN)
+	}
+
+	if len(r.queries) > 0 {
+		vars := make(map[string]string, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			vars[pairs[i]] = pairs[i+1]
+		}
+
+		for _, q := range r.queries {
+			pair, err := q.pair(vars)
+			if err != nil {
+				return "", err
+			}
+
+			pairs = append(skipVars(pairs, q.reg.VarsN), pair...)
+		}
+	}
+
+	return r.reg.url(pairs...)
+}
+
+// Meta sets a metadata value, e.g. Meta("auth", "admin"), readable by
+// middleware through Context.Route().
+func (r *Route) Meta(key string, value interface{}) *Route {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+
+	r.meta[key] = value
+	return r
+}
+
+// GetMeta returns a metadata value.
+func (r *Route) GetMeta(key string) interface{} {
+	if r == nil {
+		return nil
+	}
+
+	return r.meta[key]
+}
+
+// Tags adds tags to the route.
+func (r *Route) Tags(tags ...string) *Route {
+	for _, tag := range tags {
+		if !InArrayString(r.tags, tag) {
+			r.tags = append(r.tags, tag)
+		}
+	}
+
+	return r
+}
+
+// GetTags returns the route tags.
+func (r *Route) GetTags() []string {
+	if r == nil {
+		return nil
+	}
+
+	return r.tags
+}
+
+// HasTag reports whether the route has the tag.
+func (r *Route) HasTag(tag string) bool {
+	return InArrayString(r.GetTags(), tag)
+}
+
+// Summary sets a short description of the route.
+func (r *Route) Summary(summary string) *Route {
+	r.summary = summary
 	return r
 }
 
+// GetSummary returns the route description.
+func (r *Route) GetSummary() string {
+	if r == nil {
+		return ""
+	}
+
+	return r.summary
+}
+
+// Deprecated marks the route as deprecated.
+func (r *Route) Deprecated() *Route {
+	r.deprecated = true
+	return r
+}
+
+// IsDeprecated reports whether the route is deprecated.
+func (r *Route) IsDeprecated() bool {
+	if r == nil {
+		return false
+	}
+
+	return r.deprecated
+}
+
 func (r *Route) Handler(handler Handler) *Route {
 	if r.err == nil {
 		r.handler = handler
@@ -78,11 +352,63 @@ func (r *Route) Use(m ...MiddlewareFunc) *Route {
 	return r
 }
 
-func extractVars(input string, matches []int, names []string) []string {
-	var values []string
-	for i := range names {
-		values = append(values, input[matches[2*i+2]:matches[2*i+3]])
+// skipVars drops the name/value pairs whose name is one of names.
+func skipVars(pairs []string, names []string) []string {
+	res := make([]string, 0, len(pairs))
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if !InArrayString(names, pairs[i]) {
+			res = append(res, pairs[i], pairs[i+1])
+		}
 	}
 
-	return values
+	if len(pairs)%2 != 0 {
+		res = append(res, pairs[len(pairs)-1])
+	}
+
+	return res
+}
+
+func (r *Route) String() string {
+	method := r.method
+	if method == "" {
+		method = "*"
+	}
+
+	return StrConcat(method, " ", r.path)
+}
+
+// shadows reports whether r always wins over the later route o: same
+// method and host, no matchers, and every path variable of r accepts what
+// the one of o accepts.
+func (r *Route) shadows(o *Route) (shadow, duplicate bool) {
+	if r.reg == nil || o.reg == nil || r.method != o.method || len(r.matchers) > 0 {
+		return false, false
+	}
+
+	if r.host != nil && (o.host == nil || r.host.template != o.host.template) {
+		return false, false
+	}
+
+	rs, rp := r.reg.shape()
+	os, op := o.reg.shape()
+	if rs != os {
+		return false, false
+	}
+
+	duplicate = len(o.matchers) == 0 && (r.host == nil) == (o.host == nil)
+	for i := range rp {
+		if rp[i] == op[i] {
+			continue
+		}
+
+		duplicate = false
+		switch {
+		case rp[i] == ".*":
+		case rp[i] == "" && !matchesSlash(op[i]):
+		default:
+			return false, false
+		}
+	}
+
+	return true, duplicate
 }
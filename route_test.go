@@ -0,0 +1,181 @@
+package opm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteURL(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return nil }
+	r.GET("/users/{id:[0-9]+}", h).Name("users.show")
+	r.GET("/static/{path:.*}", h).Name("static")
+	r.GET("/tags/{tag:go|rust}", h).Name("tags")
+
+	testCases := []struct {
+		name  string
+		pairs []string
+		url   string
+		err   bool
+	}{
+		{"users.show", []string{"id", "42"}, "/users/42", false},
+		{"users.show", []string{"id", "42", "page", "2"}, "/users/42?page=2", false},
+		{"users.show", []string{"id", "abc"}, "", true},
+		{"users.show", []string{}, "", true},
+		{"users.show", []string{"id"}, "", true},
+		{"static", []string{"path", "css/a b.css"}, "/static/css/a%20b.css", false},
+		{"tags", []string{"tag", "rust"}, "/tags/rust", false},
+		{"tags", []string{"tag", "gorust"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		u, err := r.URL(tc.name, tc.pairs...)
+		if tc.err {
+			assert.Error(t, err, tc.pairs)
+			continue
+		}
+
+		assert.NoError(t, err, tc.pairs)
+		assert.Equal(t, tc.url, u)
+	}
+
+	_, err := r.URL("missing")
+	assert.True(t, errors.Is(err, ErrRouteNotFound))
+}
+
+func TestRouteHost(t *testing.T) {
+	r := Make()
+	r.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "tenant "+c.Param("tenant"))
+	}).Host("{tenant}.example.com")
+	r.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "shop "+c.Param("shop"))
+	}).Host("{shop:[a-z]+}.shop.com")
+	r.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "main")
+	})
+
+	g := r.Group("/api").Host("api.example.com:8080")
+	g.GET("/users/{id}", func(c Context) error {
+		return c.String(http.StatusOK, "api "+c.Param("id"))
+	})
+
+	testCases := []struct {
+		host string
+		path string
+		code int
+		body string
+	}{
+		{"acme.example.com", "/", http.StatusOK, "tenant acme"},
+		{"acme.example.com:443", "/", http.StatusOK, "tenant acme"},
+		{"example.com", "/", http.StatusOK, "main"},
+		{"acme.shop.com:8080", "/", http.StatusOK, "shop acme"},
+		{"acme42.shop.com:8080", "/", http.StatusOK, "main"},
+		{"api.example.com:8080", "/api/users/7", http.StatusOK, "api 7"},
+		{"api.example.com", "/api/users/7", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.host)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, tc.body, rec.Body.String(), tc.host)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	h := func(c Context) error { return nil }
+
+	r := Make()
+	r.GET("/users/{id}", h).Name("users")
+	r.POST("/users/{id}", h)
+	r.GET("/users/{id}/posts", h)
+	r.GET("/tenants", h).Host("{tenant}.example.com")
+	r.GET("/tenants", h)
+	assert.NoError(t, r.Validate())
+
+	r.GET("/users/{name}", h)
+	r.GET("/users/{id:[0-9]+}", h)
+	r.GET("/bad/{id", h)
+	r.GET("/groups/{id:(a|b)}", h)
+	r.GET("/other", h).Name("users")
+
+	err := r.Validate()
+	assert.Error(t, err)
+
+	errs, ok := err.(RouteErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 5)
+	assert.Contains(t, errs[0].Error(), "duplicate of route GET /users/{id}")
+	assert.Contains(t, errs[1].Error(), "shadowed by route GET /users/{id}")
+	assert.Contains(t, errs[2].Error(), "unbalanced braces")
+	assert.Contains(t, errs[3].Error(), "capture groups")
+	assert.Contains(t, errs[4].Error(), "already has name users")
+
+	assert.Panics(t, r.MustValidate)
+}
+
+func TestRouteMeta(t *testing.T) {
+	r := Make()
+	r.Use(func(next Handler) Handler {
+		return func(c Context) error {
+			if c.Route().GetMeta("auth") == "admin" && c.Request().Header.Get("X-Role") != "admin" {
+				return c.NoContent(http.StatusForbidden)
+			}
+
+			return next(c)
+		}
+	})
+
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	g := r.Group("/admin").Meta("auth", "admin").Tags("admin")
+	g.GET("/users", h).Tags("users").Summary("List users").Deprecated()
+	g.Group("/posts").GET("/", h)
+	r.GET("/public", h)
+
+	route := r.routeTable().routes[0]
+	assert.Equal(t, []string{"admin", "users"}, route.GetTags())
+	assert.True(t, route.HasTag("admin"))
+	assert.Equal(t, "List users", route.GetSummary())
+	assert.True(t, route.IsDeprecated())
+	assert.Equal(t, "admin", r.routeTable().routes[1].GetMeta("auth"))
+	assert.Equal(t, []string{"admin"}, r.routeTable().routes[1].GetTags())
+
+	info := route.Info()
+	info.Meta["auth"] = "none"
+	info.Tags[0] = "public"
+	assert.Equal(t, "admin", route.GetMeta("auth"))
+	assert.Equal(t, []string{"admin", "users"}, route.GetTags())
+
+	var nilRoute *Route
+	assert.Nil(t, nilRoute.GetMeta("auth"))
+	assert.False(t, nilRoute.IsDeprecated())
+
+	testCases := []struct {
+		path string
+		role string
+		code int
+	}{
+		{"/admin/users", "", http.StatusForbidden},
+		{"/admin/users", "admin", http.StatusOK},
+		{"/admin/posts", "", http.StatusForbidden},
+		{"/public", "", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("X-Role", tc.role)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+	}
+}
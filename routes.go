@@ -0,0 +1,125 @@
+package opm
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method string   `json:"method"`
+	Path   string   `json:"path"`
+	Host   string   `json:"host,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Params []string `json:"params"`
+
+	// Middleware counts the route and group middleware, not the one
+	// added with Core.Use.
+	Middleware int `json:"middleware"`
+
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Summary    string                 `json:"summary,omitempty"`
+	Deprecated bool                   `json:"deprecated,omitempty"`
+}
+
+var routesTmpl = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8" /><title>Routes</title></head>
+<body>
+<table>
+<thead><tr><th>Method</th><th>Path</th><th>Host</th><th>Name</th><th>Params</th><th>Middleware</th></tr></thead>
+<tbody>
+{{- range . }}
+<tr><td>{{ .Method }}</td><td>{{ .Path }}</td><td>{{ .Host }}</td><td>{{ .Name }}</td><td>{{ range $i, $p := .Params }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}</td><td>{{ .Middleware }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// Info returns the description of the route.
+func (r *Route) Info() RouteInfo {
+	info := RouteInfo{
+		Method:     r.method,
+		Path:       r.path,
+		Params:     append([]string{}, r.vars...),
+		Middleware: len(r.middleware),
+		Summary:    r.summary,
+		Deprecated: r.deprecated,
+	}
+
+	if len(r.meta) > 0 {
+		info.Meta = make(map[string]interface{}, len(r.meta))
+		for k, v := range r.meta {
+			info.Meta[k] = v
+		}
+	}
+
+	if len(r.tags) > 0 {
+		info.Tags = append([]string{}, r.tags...)
+	}
+
+	if r.host != nil {
+		info.Host = r.host.template
+	}
+
+	if r.namedRoutes[r.name] == r {
+		info.Name = r.name
+	}
+
+	return info
+}
+
+// Routes returns the registered routes in registration order.
+func (core *Core) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(core.routeTable().routes))
+	core.Walk(func(info RouteInfo) error {
+		routes = append(routes, info)
+		return nil
+	})
+
+	return routes
+}
+
+// Walk calls fn for each registered route, stopping at the first error.
+func (core *Core) Walk(fn func(RouteInfo) error) error {
+	for _, route := range core.routeTable().routes {
+		if route.reg == nil {
+			continue
+		}
+
+		if err := fn(route.Info()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RoutesHandler serves the route table as JSON, or as an HTML page when
+// the client accepts text/html or asks for ?format=html.
+func (core *Core) RoutesHandler() Handler {
+	return func(c Context) error {
+		routes := core.Routes()
+
+		format := c.QueryParam("format")
+		if format == "" && strings.Contains(c.Request().Header.Get(HeaderAccept), MIMETextHTML) {
+			format = "html"
+		}
+
+		if format != "html" {
+			return c.JSON(http.StatusOK, routes)
+		}
+
+		buf := new(bytes.Buffer)
+		if err := routesTmpl.Execute(buf, routes); err != nil {
+			return err
+		}
+
+		return c.HTMLBlob(http.StatusOK, buf.Bytes())
+	}
+}
@@ -0,0 +1,54 @@
+package opm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return nil }
+	m := func(next Handler) Handler { return next }
+
+	r.GET("/users/{id}", h, m).Name("users.show")
+	r.Group("/admin", m).POST("/posts/{post:[0-9]+}", h)
+	r.GET("/", h).Host("{tenant}.example.com")
+
+	routes := r.Routes()
+	assert.Equal(t, []RouteInfo{
+		{Method: http.MethodGet, Path: "/users/{id}", Name: "users.show", Params: []string{"id"}, Middleware: 1},
+		{Method: http.MethodPost, Path: "/admin/posts/{post:[0-9]+}", Params: []string{"post"}, Middleware: 1},
+		{Method: http.MethodGet, Path: "/", Host: "{tenant}.example.com", Params: []string{"tenant"}},
+	}, routes)
+
+	stop := errors.New("stop")
+	n := 0
+	err := r.Walk(func(info RouteInfo) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+
+	r.GET("/debug/routes", r.RoutesHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var res []RouteInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Len(t, res, 4)
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/routes?format=html", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.True(t, strings.Contains(rec.Body.String(), "<td>users.show</td>"))
+}
@@ -0,0 +1,101 @@
+package opm
+
+import (
+	"errors"
+	"net/http"
+)
+
+// routeTable holds the routes of a Core. Core.Reload builds a new table
+// and swaps it atomically, requests in flight keep the one they started
+// with.
+type routeTable struct {
+	routes      RouteList
+	namedRoutes RouteNames
+	tree        *routeTree
+
+	// scopes are the groups with their own not found, method not allowed
+	// or error handlers.
+	scopes []*Group
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{
+		namedRoutes: make(map[string]*Route),
+		tree:        newRouteTree(),
+	}
+}
+
+func (t *routeTable) addScope(g *Group) {
+	for _, s := range t.scopes {
+		if s == g {
+			return
+		}
+	}
+
+	t.scopes = append(t.scopes, g)
+}
+
+// scope returns the group with handlers and the longest prefix containing
+// the request.
+func (t *routeTable) scope(req *http.Request) *Group {
+	var scope *Group
+	for _, g := range t.scopes {
+		if g.contains(req) && (scope == nil || len(g.prefix) > len(scope.prefix)) {
+			scope = g
+		}
+	}
+
+	return scope
+}
+
+var errReloadServing = errors.New("reload: routes added through a group of the serving core")
+
+// routeTable returns the current route table.
+func (core *Core) routeTable() *routeTable {
+	return core.table.Load().(*routeTable)
+}
+
+// addScope registers the group handlers in the current table, unless
+// Reload runs.
+func (core *Core) addScope(g *Group) {
+	if core.reloading {
+		core.reloadErr = errReloadServing
+		return
+	}
+
+	core.routeTable().addScope(g)
+}
+
+// Reload replaces every route at runtime: fn registers the new routes on
+// a staging Core whose table is then swapped in atomically. It is safe to
+// call while serving, unlike adding routes to the Core directly.
+//
+// Only routes, groups made from the staging Core and their handlers are
+// taken from it. Reload returns an error, and keeps the current routes,
+// when fn sets middleware, error pages or handlers of the staging Core,
+// or adds routes or group handlers through a group of the serving Core,
+// which are then discarded.
+func (core *Core) Reload(fn func(*Core)) error {
+	core.Lock()
+	defer core.Unlock()
+
+	core.reloading, core.reloadErr = true, nil
+	defer func() { core.reloading = false }()
+
+	staging := &Core{}
+	staging.table.Store(newRouteTable())
+	fn(staging)
+
+	if core.reloadErr != nil {
+		return core.reloadErr
+	}
+
+	if len(staging.middleware) > 0 || len(staging.premiddleware) > 0 || staging.errorPages != nil ||
+		staging.NotFoundHandler != nil || staging.MethodNotAllowedHandler != nil ||
+		staging.SystemErrorHandler != nil || staging.HTTPErrorHandler != nil {
+		return errors.New("reload: only routes and groups can be registered on the staging core")
+	}
+
+	core.table.Store(staging.routeTable())
+	return nil
+}
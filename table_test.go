@@ -0,0 +1,93 @@
+package opm
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+	r := Make()
+	r.GET("/page", func(c Context) error { return c.String(http.StatusOK, "v0") })
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				code, _ := request(http.MethodGet, "/page", r)
+				assert.Equal(t, http.StatusOK, code)
+			}
+		}()
+	}
+
+	for i := 1; i <= 10; i++ {
+		v := "v" + strconv.Itoa(i)
+		err := r.Reload(func(core *Core) {
+			core.GET("/page", func(c Context) error { return c.String(http.StatusOK, v) }).Name("page")
+		})
+		assert.NoError(t, err)
+	}
+
+	close(stop)
+	wg.Wait()
+
+	_, body := request(http.MethodGet, "/page", r)
+	assert.Equal(t, "v10", body)
+	assert.NotNil(t, r.GetRoute("page"))
+	assert.Len(t, r.Routes(), 1)
+}
+
+func TestReloadScope(t *testing.T) {
+	r := Make()
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	served := r.Group("/old")
+
+	err := r.Reload(func(core *Core) {
+		g := core.Group("/api").NotFound(func(c Context) error {
+			return c.String(http.StatusNotFound, "api not found")
+		})
+		g.GET("/users", h)
+	})
+	assert.NoError(t, err)
+
+	code, body := request(http.MethodGet, "/api/missing", r)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "api not found", body)
+
+	testCases := []func(*Core){
+		func(core *Core) { core.Use(func(next Handler) Handler { return next }) },
+		func(core *Core) { core.Pre(func(next Handler) Handler { return next }) },
+		func(core *Core) { core.NotFoundHandler = h },
+		func(core *Core) { core.ErrorPages(map[int]string{http.StatusNotFound: "404"}) },
+		func(core *Core) { served.GET("/users", h) },
+		func(core *Core) { served.NotFound(h) },
+	}
+
+	for i, fn := range testCases {
+		assert.Error(t, r.Reload(fn), i)
+
+		code, _ := request(http.MethodGet, "/api/users", r)
+		assert.Equal(t, http.StatusOK, code, i)
+	}
+
+	code, _ = request(http.MethodGet, "/old/users", r)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Len(t, r.Routes(), 1)
+	assert.Len(t, r.routeTable().scopes, 1)
+
+	served.GET("/users", h)
+	code, _ = request(http.MethodGet, "/old/users", r)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -1,6 +1,8 @@
 package template
 
 import (
+	"errors"
+	"fmt"
 	"html/template"
 
 	"github.com/boyfinal/opm"
@@ -17,3 +19,21 @@ func format(v interface{}) string {
 func add(a, b int) int {
 	return a + b
 }
+
+// url builds the path of a named route, e.g. {{ url "users.show" "id" .ID }}
+func (t *Template) url(name string, pairs ...interface{}) (string, error) {
+	if t.router == nil {
+		return "", errors.New("router not registered")
+	}
+
+	params := make([]string, len(pairs))
+	for i, v := range pairs {
+		if s := opm.NumFormat(v); s != "" || v == nil {
+			params[i] = s
+		} else {
+			params[i] = fmt.Sprint(v)
+		}
+	}
+
+	return t.router.URL(name, params...)
+}
@@ -7,12 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/boyfinal/opm"
 )
 
 type Template struct {
 	sync.Mutex
 
 	FuncMap   template.FuncMap
+	router    *opm.Core
 	dirBase   string
 	dirView   string
 	dirLayout string
@@ -41,6 +44,12 @@ func (t *Template) DirLayout(name string) *Template {
 	return t
 }
 
+// Router sets the Core used by the `url` template function.
+func (t *Template) Router(core *opm.Core) *Template {
+	t.router = core
+	return t
+}
+
 func (t *Template) Render(w io.Writer, name string, body interface{}) error {
 	if err := t.Load(name); err != nil {
 		return err
@@ -79,6 +88,7 @@ func (t *Template) Load(name string) error {
 		"raw":    rawhtml,
 		"format": format,
 		"add":    add,
+		"url":    t.url,
 	})
 
 	tmp = tmp.Funcs(t.FuncMap)
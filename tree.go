@@ -0,0 +1,357 @@
+package opm
+
+import (
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+type (
+	// routeTree is a radix tree of route path templates. Static text is
+	// stored in prefix-compressed nodes, `{name}` and `{name:pattern}`
+	// variables are stored as param nodes keyed by their pattern.
+	routeTree struct {
+		root      node
+		maxParams int
+	}
+
+	node struct {
+		prefix   string
+		indices  string
+		children []*node
+		params   []*node
+		routes   []*Route
+
+		// param node only
+		pattern string
+		re      *regexp.Regexp
+		wide    bool
+	}
+
+	// lookup holds the state of a single tree search.
+	lookup struct {
+		req      *http.Request
+		method   string
+		values   []string
+		route    *Route
+		allowed  bool
+		mismatch bool
+		methods  []string
+	}
+)
+
+func newRouteTree() *routeTree {
+	return &routeTree{}
+}
+
+// insert adds the route under its path template and returns the leaf node.
+func (t *routeTree) insert(path string, route *Route) (*node, error) {
+	idxs, err := braceIndices(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &t.root
+	var end int
+	for i := 0; i < len(idxs); i += 2 {
+		if raw := path[end:idxs[i]]; raw != "" {
+			n = n.addStatic(raw)
+		}
+
+		end = idxs[i+1]
+		parts := strings.SplitN(path[idxs[i]+1:end-1], ":", 2)
+		patt := ""
+		if len(parts) == 2 {
+			patt = expandPattern(parts[1])
+		}
+
+		if n, err = n.addParam(patt); err != nil {
+			return nil, err
+		}
+	}
+
+	if raw := path[end:]; raw != "" {
+		n = n.addStatic(raw)
+	}
+
+	if np := len(idxs) / 2; np > t.maxParams {
+		t.maxParams = np
+	}
+
+	n.routes = append(n.routes, route)
+	return n, nil
+}
+
+// remove detaches the route from the leaf it was inserted at.
+func (t *routeTree) remove(leaf *node, route *Route) {
+	for i, r := range leaf.routes {
+		if r == route {
+			leaf.routes = append(leaf.routes[:i:i], leaf.routes[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *node) addStatic(s string) *node {
+	for s != "" {
+		i := strings.IndexByte(n.indices, s[0])
+		if i < 0 {
+			child := &node{prefix: s}
+			n.indices += s[:1]
+			n.children = append(n.children, child)
+			return child
+		}
+
+		child := n.children[i]
+		l := commonPrefix(s, child.prefix)
+		if l < len(child.prefix) {
+			split := &node{
+				prefix:   child.prefix[:l],
+				indices:  child.prefix[l : l+1],
+				children: []*node{child},
+			}
+
+			child.prefix = child.prefix[l:]
+			n.children[i] = split
+			child = split
+		}
+
+		n = child
+		s = s[l:]
+	}
+
+	return n
+}
+
+func (n *node) addParam(pattern string) (*node, error) {
+	for _, p := range n.params {
+		if p.pattern == pattern {
+			return p, nil
+		}
+	}
+
+	p := &node{pattern: pattern}
+	if pattern != "" {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, err
+		}
+
+		p.re = re
+		p.wide = matchesSlash(pattern)
+	}
+
+	n.params = append(n.params, p)
+	return p, nil
+}
+
+// find walks the tree for the remaining path, static children first, then
+// params in registration order, backtracking until a route accepts.
+func (n *node) find(path string, l *lookup) bool {
+	if path == "" && len(n.routes) > 0 && l.accept(n) {
+		return true
+	}
+
+	if path != "" {
+		if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+			child := n.children[i]
+			if strings.HasPrefix(path, child.prefix) && child.find(path[len(child.prefix):], l) {
+				return true
+			}
+		}
+	}
+
+	for _, p := range n.params {
+		if p.findParam(path, l) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *node) findParam(path string, l *lookup) bool {
+	end := len(path)
+	if !n.wide {
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			end = i
+		}
+	}
+
+	min := 0
+	if n.re == nil {
+		min = 1
+	}
+
+	// A param without children can only consume the rest of the path.
+	if len(n.children) == 0 && len(n.params) == 0 {
+		if end != len(path) {
+			return false
+		}
+		min = end
+	}
+
+	k := len(l.values)
+	for e := end; e >= min; e-- {
+		v := path[:e]
+		if n.re != nil && !n.re.MatchString(v) {
+			continue
+		}
+
+		l.values = append(l.values[:k], v)
+		if n.find(path[e:], l) {
+			return true
+		}
+	}
+
+	l.values = l.values[:k]
+	return false
+}
+
+// findFold walks the tree like find, matching the static text without
+// case, and appends the path with the registered static text and the
+// param values of the request to canon.
+func (n *node) findFold(path string, l *lookup, canon []byte) ([]byte, bool) {
+	if path == "" && len(n.routes) > 0 && l.exists(n) {
+		return canon, true
+	}
+
+	for _, child := range n.children {
+		if len(path) >= len(child.prefix) && strings.EqualFold(path[:len(child.prefix)], child.prefix) {
+			if res, ok := child.findFold(path[len(child.prefix):], l, append(canon, child.prefix...)); ok {
+				return res, true
+			}
+		}
+	}
+
+	for _, p := range n.params {
+		if res, ok := p.findParamFold(path, l, canon); ok {
+			return res, true
+		}
+	}
+
+	return nil, false
+}
+
+func (n *node) findParamFold(path string, l *lookup, canon []byte) ([]byte, bool) {
+	end := len(path)
+	if !n.wide {
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			end = i
+		}
+	}
+
+	min := 0
+	if n.re == nil {
+		min = 1
+	}
+
+	if len(n.children) == 0 && len(n.params) == 0 {
+		if end != len(path) {
+			return nil, false
+		}
+		min = end
+	}
+
+	for e := end; e >= min; e-- {
+		v := path[:e]
+		if n.re != nil && !n.re.MatchString(v) {
+			continue
+		}
+
+		if res, ok := n.findFold(path[e:], l, append(canon, v...)); ok {
+			return res, true
+		}
+	}
+
+	return nil, false
+}
+
+// exists reports whether a route of the leaf matches the request, whatever
+// its method and matchers.
+func (l *lookup) exists(n *node) bool {
+	l.allowed, l.mismatch = false, false
+	return l.accept(n) || l.allowed || l.mismatch
+}
+
+// accept picks the first route of the leaf matching the request method
+// and matchers. Routes for another host are skipped without counting as a
+// method miss.
+func (l *lookup) accept(n *node) bool {
+	for _, route := range n.routes {
+		if route.err != nil || route.handler == nil {
+			continue
+		}
+
+		if route.host != nil && !route.host.Math(l.req) {
+			continue
+		}
+
+		if route.method != l.method {
+			l.allowed = true
+			if route.method != "" && !InArrayString(l.methods, route.method) {
+				l.methods = append(l.methods, route.method)
+			}
+			continue
+		}
+
+		if !route.match(l.req) {
+			l.mismatch = true
+			continue
+		}
+
+		l.route = route
+		l.values = route.setValues(l.req, l.values)
+		return true
+	}
+
+	return false
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
+
+// matchesSlash reports whether the pattern may match a '/' and so span
+// several path segments.
+func matchesSlash(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+
+	return reMatchesSlash(re)
+}
+
+func reMatchesSlash(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if r == '/' {
+				return true
+			}
+		}
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= '/' && '/' <= re.Rune[i+1] {
+				return true
+			}
+		}
+	}
+
+	for _, sub := range re.Sub {
+		if reMatchesSlash(sub) {
+			return true
+		}
+	}
+
+	return false
+}
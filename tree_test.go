@@ -0,0 +1,106 @@
+package opm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeMatch(t *testing.T) {
+	r := Make()
+	h := func(name string) Handler {
+		return func(c Context) error {
+			return c.String(http.StatusOK, name)
+		}
+	}
+
+	r.GET("/", h("root"))
+	r.GET("/users", h("users"))
+	r.GET("/users/new", h("users.new"))
+	r.GET("/users/{id:[0-9]+}", h("users.id"))
+	r.GET("/users/{name}", h("users.name"))
+	r.GET("/users/{id}/posts", h("users.posts"))
+	r.GET("/files/{name}.{ext}", h("files"))
+	r.GET("/static/{path:.*}", h("static"))
+	r.POST("/users/list", h("users.list"))
+	r.GET("/users/{name:[a-z]+}/list", h("users.name.list"))
+
+	testCases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+		params map[string]string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "root", nil},
+		{http.MethodGet, "/users", http.StatusOK, "users", nil},
+		{http.MethodGet, "/users/", http.StatusNotFound, "", nil},
+		{http.MethodGet, "/users/new", http.StatusOK, "users.new", nil},
+		{http.MethodGet, "/users/42", http.StatusOK, "users.id", map[string]string{"id": "42"}},
+		{http.MethodGet, "/users/joe", http.StatusOK, "users.name", map[string]string{"name": "joe"}},
+		{http.MethodGet, "/users/list", http.StatusOK, "users.name", map[string]string{"name": "list"}},
+		{http.MethodGet, "/users/7/posts", http.StatusOK, "users.posts", map[string]string{"id": "7"}},
+		{http.MethodGet, "/users/abc/list", http.StatusOK, "users.name.list", map[string]string{"name": "abc"}},
+		{http.MethodGet, "/files/a.b.tar", http.StatusOK, "files", map[string]string{"name": "a.b", "ext": "tar"}},
+		{http.MethodGet, "/static/css/app.css", http.StatusOK, "static", map[string]string{"path": "css/app.css"}},
+		{http.MethodGet, "/static/", http.StatusOK, "static", map[string]string{"path": ""}},
+		{http.MethodGet, "/nope", http.StatusNotFound, "", nil},
+		{http.MethodDelete, "/users/new", http.StatusMethodNotAllowed, "", nil},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		var match RouteMatch
+		ok := r.Match(req, &match)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+
+		if tc.code != http.StatusOK {
+			assert.False(t, ok, tc.path)
+			continue
+		}
+
+		assert.True(t, ok, tc.path)
+		assert.Equal(t, tc.body, rec.Body.String(), tc.path)
+		assert.Equal(t, len(tc.params), len(match.PValues), tc.path)
+		for i, name := range match.PNames {
+			assert.Equal(t, tc.params[name], match.PValues[i], tc.path)
+		}
+	}
+}
+
+func TestTreeParamAllocs(t *testing.T) {
+	r := Make()
+	r.GET("/users/{id}/posts/{post:[0-9]+}", func(c Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1/posts/2", nil)
+	match := RouteMatch{PValues: make([]string, 0, 2)}
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Match(req, &match)
+	})
+
+	assert.Equal(t, float64(0), allocs)
+	assert.Equal(t, []string{"1", "2"}, match.PValues)
+}
+
+func BenchmarkTreeMatch(b *testing.B) {
+	r := Make()
+	h := func(c Context) error { return nil }
+	for _, p := range []string{"/", "/users", "/users/{id}", "/users/{id}/posts", "/posts/{id:[0-9]+}", "/static/{path:.*}"} {
+		r.GET(p, h)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42/posts", nil)
+	match := RouteMatch{PValues: make([]string, 0, 1)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Match(req, &match)
+	}
+}
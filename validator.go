@@ -0,0 +1,264 @@
+package opm
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	// Validator validates the values bound from a request.
+	Validator interface {
+		Validate(i interface{}) error
+	}
+
+	// TagValidator validates structs by their `validate` tags, e.g.
+	// `validate:"required,email"`. The rules are required, omitempty,
+	// email, url, min=n, max=n, len=n and oneof=a b. Strings are measured
+	// in characters, slices and maps in items.
+	TagValidator struct{}
+
+	// FieldError describes a field that failed a validation rule.
+	FieldError struct {
+		Field   string `json:"field"`
+		Rule    string `json:"rule"`
+		Param   string `json:"param,omitempty"`
+		Message string `json:"message"`
+	}
+
+	// ValidationErrors lists the fields that failed validation, answered
+	// with a 422 and the message of each field.
+	ValidationErrors []*FieldError
+)
+
+// NewValidator returns the built-in struct tag validator.
+func NewValidator() *TagValidator {
+	return &TagValidator{}
+}
+
+func (e *FieldError) Error() string {
+	return StrConcat(e.Field, " ", e.Message)
+}
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, len(ve))
+	for i, e := range ve {
+		messages[i] = e.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the struct, or pointer to struct, i. It returns
+// ValidationErrors when a field fails.
+func (v *TagValidator) Validate(i interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(i))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: %T is not a struct", i)
+	}
+
+	var errs ValidationErrors
+	if err := v.validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (v *TagValidator) validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		if ft.PkgPath != "" && !ft.Anonymous {
+			continue
+		}
+
+		fv := rv.Field(i)
+		name := fieldName(ft)
+		if !ft.Anonymous {
+			name = StrConcat(prefix, name)
+		} else {
+			name = prefix
+		}
+
+		if tag := ft.Tag.Get("validate"); tag != "" && tag != "-" {
+			fe, err := validateField(fv, name, tag)
+			if err != nil {
+				return err
+			}
+
+			if fe != nil {
+				*errs = append(*errs, fe)
+				continue
+			}
+		}
+
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			if !ft.Anonymous {
+				name = StrConcat(name, ".")
+			}
+
+			if err := v.validateStruct(fv, name, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns the JSON name of the field.
+func fieldName(ft reflect.StructField) string {
+	if name := strings.Split(ft.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+
+	return ft.Name
+}
+
+// validateField returns the first rule of tag the field fails.
+func validateField(fv reflect.Value, name, tag string) (*FieldError, error) {
+	for _, rule := range strings.Split(tag, ",") {
+		param := ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			rule, param = rule[:i], rule[i+1:]
+		}
+
+		if rule == "omitempty" {
+			if fv.IsZero() {
+				return nil, nil
+			}
+
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				if rule == "required" {
+					return newFieldError(name, rule, param, "is required"), nil
+				}
+
+				return nil, nil
+			}
+
+			fv = fv.Elem()
+		}
+
+		message, err := checkRule(fv, rule, param)
+		if err != nil {
+			return nil, fmt.Errorf("validate: field %s: %v", name, err)
+		}
+
+		if message != "" {
+			return newFieldError(name, rule, param, message), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newFieldError(field, rule, param, message string) *FieldError {
+	return &FieldError{Field: field, Rule: rule, Param: param, Message: message}
+}
+
+// checkRule returns the message of the failed rule, empty when it passes.
+func checkRule(fv reflect.Value, rule, param string) (string, error) {
+	switch rule {
+	case "required":
+		if fv.IsZero() || (isCollection(fv) && fv.Len() == 0) {
+			return "is required", nil
+		}
+	case "email":
+		s := fmt.Sprint(fv.Interface())
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be a valid email address", nil
+		}
+	case "url":
+		if u, err := url.ParseRequestURI(fmt.Sprint(fv.Interface())); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL", nil
+		}
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s parameter %q", rule, param)
+		}
+
+		size, unit, ok := measure(fv)
+		if !ok {
+			return "", fmt.Errorf("%s does not apply to %s", rule, fv.Type())
+		}
+
+		switch {
+		case rule == "min" && size < limit:
+			return StrConcat("must be at least ", param, unit), nil
+		case rule == "max" && size > limit:
+			return StrConcat("must be at most ", param, unit), nil
+		case rule == "len" && size != limit:
+			return StrConcat("must be exactly ", param, unit), nil
+		}
+	case "oneof":
+		if !InArrayString(strings.Fields(param), fmt.Sprint(fv.Interface())) {
+			return StrConcat("must be one of: ", strings.Join(strings.Fields(param), ", ")), nil
+		}
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule)
+	}
+
+	return "", nil
+}
+
+func isCollection(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+
+	return false
+}
+
+// measure returns the length of strings and collections, or the value of
+// numbers, with the unit used in messages.
+func measure(fv reflect.Value) (float64, string, bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), " characters", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), " items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), "", true
+	}
+
+	return 0, "", false
+}
+
+// Validate validates v with the validator registered on the Core.
+func (c *context) Validate(v interface{}) error {
+	if c.validator == nil {
+		return ErrValidatorNotRegistered
+	}
+
+	return c.validator.Validate(v)
+}
+
+// validationErrorHandler answers the failed fields with a 422.
+func validationErrorHandler(ve ValidationErrors, c Context) error {
+	code := http.StatusUnprocessableEntity
+	return c.JSON(code, Map{"message": http.StatusText(code), "errors": ve})
+}
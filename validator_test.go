@@ -0,0 +1,108 @@
+package opm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validateUser struct {
+	Name    string           `json:"name" validate:"required,min=3,max=8"`
+	Email   string           `json:"email" validate:"required,email"`
+	Site    string           `json:"site" validate:"omitempty,url"`
+	Role    string           `json:"role" validate:"oneof=admin user"`
+	Age     int              `json:"age" validate:"min=18"`
+	Tags    []string         `json:"tags" validate:"max=2"`
+	Nick    *string          `json:"nick" validate:"omitempty,min=2"`
+	Address *validateAddress `json:"address"`
+}
+
+func TestTagValidator(t *testing.T) {
+	v := NewValidator()
+
+	valid := validateUser{Name: "Jon", Email: "jon@example.com", Role: "admin", Age: 20}
+	assert.NoError(t, v.Validate(&valid))
+
+	nick := "j"
+	invalid := validateUser{
+		Name:    "Jo",
+		Email:   "jon",
+		Site:    "example",
+		Role:    "root",
+		Age:     17,
+		Tags:    []string{"a", "b", "c"},
+		Nick:    &nick,
+		Address: &validateAddress{},
+	}
+
+	err := v.Validate(invalid)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		fields := map[string]string{}
+		for _, fe := range err.(ValidationErrors) {
+			fields[fe.Field] = fe.Message
+		}
+
+		assert.Equal(t, map[string]string{
+			"name":         "must be at least 3 characters",
+			"email":        "must be a valid email address",
+			"site":         "must be a valid URL",
+			"role":         "must be one of: admin, user",
+			"age":          "must be at least 18",
+			"tags":         "must be at most 2 items",
+			"nick":         "must be at least 2 characters",
+			"address.city": "is required",
+		}, fields)
+	}
+
+	assert.Error(t, v.Validate(struct {
+		Name string `validate:"unknown"`
+	}{}))
+}
+
+func TestContextValidate(t *testing.T) {
+	r := Make()
+	r.POST("/users", func(c Context) error {
+		u := new(validateUser)
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+
+		return c.Validate(u)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Jon"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	r = Make()
+	r.Validator = NewValidator()
+	c := r.NewContext(nil, nil)
+	assert.NoError(t, c.Validate(&validateUser{Name: "Jon", Email: "jon@example.com", Role: "user", Age: 18}))
+
+	r.POST("/users", func(c Context) error {
+		return c.Validate(&validateUser{Name: "Jon", Role: "user", Age: 18})
+	})
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	body := struct {
+		Errors []*FieldError `json:"errors"`
+	}{}
+
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body)) && assert.Len(t, body.Errors, 1) {
+		assert.Equal(t, "email", body.Errors[0].Field)
+		assert.Equal(t, "required", body.Errors[0].Rule)
+	}
+}
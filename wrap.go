@@ -0,0 +1,78 @@
+package opm
+
+import (
+	sdtContext "context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// FromRequest returns the Context of a request passed to a wrapped
+// `http.Handler`, or nil. Path params and values set with Context.Set are
+// read through it.
+func FromRequest(r *http.Request) Context {
+	c, _ := r.Context().Value(contextKey{}).(Context)
+	return c
+}
+
+// withContext returns the request of c carrying c in its context.
+func withContext(c Context) *http.Request {
+	r := c.Request()
+	if FromRequest(r) == c {
+		return r
+	}
+
+	return r.WithContext(sdtContext.WithValue(r.Context(), contextKey{}, c))
+}
+
+// WrapHandler wraps `http.Handler` into `Handler`.
+func WrapHandler(h http.Handler) Handler {
+	return func(c Context) error {
+		h.ServeHTTP(c.Response(), withContext(c))
+		return nil
+	}
+}
+
+// WrapMiddleware wraps `func(http.Handler) http.Handler` into
+// `MiddlewareFunc`. The request and response writer passed on by the
+// middleware are used by the next handlers.
+func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next Handler) Handler {
+		return func(c Context) (err error) {
+			rq, rw := c.Request(), c.Response()
+			defer func() {
+				c.SetRequest(rq)
+				c.SetResponse(rw)
+			}()
+
+			m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.SetResponse(w)
+				err = next(c)
+			})).ServeHTTP(rw, withContext(c))
+
+			return
+		}
+	}
+}
+
+// Mount forwards every method on the prefix and its sub-paths to h, with
+// the prefix stripped from the request path.
+func (core *Core) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) []*Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := WrapHandler(http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" {
+			r.URL.Path = "/"
+		}
+
+		h.ServeHTTP(w, r)
+	})))
+
+	routes := core.Any(StrConcat(prefix, "/{path:.*}"), handler, m...)
+	if prefix != "" {
+		routes = append(routes, core.Any(prefix, handler, m...)...)
+	}
+
+	return routes
+}
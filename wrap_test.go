@@ -0,0 +1,77 @@
+package opm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapHandler(t *testing.T) {
+	r := Make()
+	r.GET("/users/{id}", WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c := FromRequest(req)
+		w.Write([]byte(c.Param("id") + " " + c.Get("user").(string)))
+	})), func(next Handler) Handler {
+		return func(c Context) error {
+			c.Set("user", "joe")
+			return next(c)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "7 joe", rec.Body.String())
+	assert.Nil(t, FromRequest(req))
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	r := Make()
+	r.Use(WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Wrapped", FromRequest(req).Request().URL.Path)
+			next.ServeHTTP(w, req)
+		})
+	}))
+	r.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "/", rec.Header().Get("X-Wrapped"))
+	assert.Equal(t, "OK", rec.Body.String())
+}
+
+func TestMount(t *testing.T) {
+	r := Make()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Method + " " + req.URL.Path))
+	})
+
+	r.Mount("/legacy/", mux)
+	r.GET("/legacy/new", func(c Context) error { return c.String(http.StatusOK, "new") })
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/legacy", "GET /"},
+		{http.MethodGet, "/legacy/", "GET /"},
+		{http.MethodPost, "/legacy/a/b", "POST /a/b"},
+		{http.MethodDelete, "/legacy/a", "DELETE /a"},
+		{http.MethodGet, "/legacy/new", "new"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, tc.body, rec.Body.String(), tc.path)
+	}
+}